@@ -0,0 +1,62 @@
+package certmgr
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	TrustBundleScopeCA        = "ca"
+	TrustBundleScopeNamespace = "namespace"
+	DefaultTrustBundleKey     = "ca.crt"
+)
+
+// BundleGVR identifies the trust-manager Bundle resource, used through the
+// dynamic client to avoid depending on trust-manager.
+var BundleGVR = schema.GroupVersionResource{
+	Group:    "trust.cert-manager.io",
+	Version:  "v1alpha1",
+	Resource: "bundles",
+}
+
+// BundleName returns the name of the Bundle holding the CA of the given
+// namespace and name, or all CAs of the namespace when the scope is the
+// namespace.
+func BundleName(scope, namespace, caName string) string {
+	if scope == TrustBundleScopeNamespace {
+		return clusterScopedName(namespace)
+	}
+	return clusterScopedName(namespace, caName)
+}
+
+// NewBundle returns a trust-manager Bundle distributing the given PEM
+// encoded CA certificates to the target namespaces. The certificates are
+// given inline, as trust-manager only reads Secrets from its own namespace.
+func NewBundle(name, namespace, caPEM string, config TrustBundles) *unstructured.Unstructured {
+	target := map[string]any{
+		"configMap": map[string]any{
+			"key": config.Key,
+		},
+	}
+	if config.TargetNamespaces != nil {
+		selector, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(config.TargetNamespaces)
+		target["namespaceSelector"] = selector
+	}
+	bundle := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"sources": []any{
+					map[string]any{"inLine": caPEM},
+				},
+				"target": target,
+			},
+		},
+	}
+	bundle.SetAPIVersion(BundleGVR.GroupVersion().String())
+	bundle.SetKind("Bundle")
+	bundle.SetName(name)
+	bundle.SetLabels(map[string]string{NamespaceLabel: namespace})
+	bundle.SetAnnotations(map[string]string{SpecHashAnnotation: hashOf(bundle.Object["spec"])})
+	return bundle
+}
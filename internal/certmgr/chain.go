@@ -0,0 +1,104 @@
+package certmgr
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v2 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ChainIssuerRef returns the issuer of the intermediate at the given index
+// of the chain: the root issuer of the namespace for the first one, and the
+// previous intermediate for the others.
+func ChainIssuerRef(namespace string, chain []Intermediate, index int) v2.ObjectReference {
+	if index > 0 {
+		return v2.ObjectReference{Name: chain[index-1].Name}
+	}
+	rootIssuer, clusterIssuer := GetRootIssuer(namespace)
+	if rootIssuer == "" {
+		return v2.ObjectReference{Name: DefaultRootIssuerName}
+	}
+	ref := v2.ObjectReference{Name: rootIssuer}
+	if clusterIssuer {
+		ref.Kind = "ClusterIssuer"
+	}
+	return ref
+}
+
+func NewIntermediateCertificate(namespace string, chain []Intermediate, index int) *cm.Certificate {
+	intermediate := chain[index]
+	subject := intermediate.Subject
+	if subject == "" {
+		subject = intermediate.Name
+	}
+	cmCert := &cm.Certificate{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Certificate",
+			APIVersion: cm.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      intermediate.Name,
+			Namespace: namespace,
+		},
+		Spec: cm.CertificateSpec{
+			CommonName: subject,
+			Duration: &v1.Duration{
+				Duration: DefaultExpiration(),
+			},
+			SecretName: intermediate.Name,
+			IssuerRef:  ChainIssuerRef(namespace, chain, index),
+			IsCA:       true,
+		},
+	}
+	cmCert.Annotations = map[string]string{SpecHashAnnotation: hashOf(cmCert.Spec)}
+	return cmCert
+}
+
+func NewIntermediateIssuer(namespace string, intermediate Intermediate) *cm.Issuer {
+	issuer := &cm.Issuer{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Issuer",
+			APIVersion: cm.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      intermediate.Name,
+			Namespace: namespace,
+		},
+		Spec: cm.IssuerSpec{
+			IssuerConfig: cm.IssuerConfig{
+				CA: &cm.CAIssuer{
+					SecretName: intermediate.Name,
+				},
+			},
+		},
+	}
+	issuer.Annotations = map[string]string{SpecHashAnnotation: hashOf(issuer.Spec)}
+	return issuer
+}
+
+// ChainLevels returns the number of CA levels expected below the
+// intermediate at the given index of the chain: the following intermediates
+// and the CA of the Skupper site.
+func ChainLevels(chain []Intermediate, index int) int {
+	return len(chain) - index
+}
+
+// PathLenProblem tells whether the path length of an issued intermediate CA
+// allows the given number of CA levels below it. It returns unconstrained
+// as true when no path length is set, which is always the case for the CAs
+// issued by cert-manager: it cannot set one, so the depth of the chain is
+// only enforced by issuers setting it themselves.
+func PathLenProblem(cert *x509.Certificate, levels int) (string, bool) {
+	if !cert.IsCA {
+		return "not a CA certificate", false
+	}
+	if cert.MaxPathLen < 0 || (cert.MaxPathLen == 0 && !cert.MaxPathLenZero) {
+		return "", true
+	}
+	if cert.MaxPathLen < levels {
+		return fmt.Sprintf("path length %d does not allow the %d CA levels below it", cert.MaxPathLen, levels), false
+	}
+	return "", false
+}
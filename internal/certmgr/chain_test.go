@@ -0,0 +1,80 @@
+package certmgr
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateChain(t *testing.T) {
+	intermediates := func(names ...string) *Chain {
+		chain := &Chain{}
+		for _, name := range names {
+			chain.Intermediates = append(chain.Intermediates, Intermediate{Name: name})
+		}
+		return chain
+	}
+	var tooLong []string
+	for i := 0; i <= MaxChainIntermediates; i++ {
+		tooLong = append(tooLong, fmt.Sprintf("intermediate-%d", i))
+	}
+	tests := []struct {
+		name  string
+		chain *Chain
+		err   string
+	}{
+		{name: "none"},
+		{name: "empty", chain: intermediates()},
+		{name: "valid", chain: intermediates("organization", "team")},
+		{name: "missing name", chain: intermediates("organization", ""), err: "chain.intermediates[1]: a unique name is required"},
+		{name: "duplicate name", chain: intermediates("organization", "organization"), err: "chain.intermediates[1]: a unique name is required"},
+		{name: "too long", chain: intermediates(tooLong...), err: fmt.Sprintf("at most %d intermediates", MaxChainIntermediates)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateChain("chain", test.chain)
+			if test.err == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestChainLevels(t *testing.T) {
+	chain := []Intermediate{{Name: "organization"}, {Name: "team"}}
+	if levels := ChainLevels(chain, 0); levels != 2 {
+		t.Errorf("levels below the first intermediate = %d, want 2", levels)
+	}
+	if levels := ChainLevels(chain, 1); levels != 1 {
+		t.Errorf("levels below the last intermediate = %d, want 1", levels)
+	}
+}
+
+func TestPathLenProblem(t *testing.T) {
+	tests := []struct {
+		name          string
+		cert          *x509.Certificate
+		levels        int
+		problem       string
+		unconstrained bool
+	}{
+		{"not a CA", &x509.Certificate{MaxPathLen: -1}, 1, "not a CA certificate", false},
+		{"unset", &x509.Certificate{IsCA: true, MaxPathLen: -1}, 1, "", true},
+		{"zero value", &x509.Certificate{IsCA: true}, 1, "", true},
+		{"zero", &x509.Certificate{IsCA: true, MaxPathLenZero: true}, 1, "path length 0 does not allow the 1 CA levels below it", false},
+		{"too short", &x509.Certificate{IsCA: true, MaxPathLen: 1}, 2, "path length 1 does not allow the 2 CA levels below it", false},
+		{"enough", &x509.Certificate{IsCA: true, MaxPathLen: 2}, 2, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem, unconstrained := PathLenProblem(test.cert, test.levels)
+			if problem != test.problem || unconstrained != test.unconstrained {
+				t.Errorf("PathLenProblem() = %q, %v, want %q, %v", problem, unconstrained, test.problem, test.unconstrained)
+			}
+		})
+	}
+}
@@ -1,20 +1,68 @@
 package certmgr
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 const (
-	DefaultRootIssuerName = "skupper-issuer"
+	DefaultRootIssuerName      = "skupper-issuer"
+	ConfigKey                  = "config.yaml"
+	DefaultAdoptionRenewBefore = 30 * 24 * time.Hour
+	DefaultCAGracePeriod       = 7 * 24 * time.Hour
+	DefaultCAPublishDelay      = 5 * time.Minute
+
+	DefaultCertManagerServiceAccount = "cert-manager"
+	DefaultCertManagerNamespace      = "cert-manager"
+	DefaultSharedRootName            = "skupper-root"
+	MaxChainIntermediates            = 4
 )
 
 type Config struct {
-	RootIssuer string
-	Issuer     string
-	IssuerMap  map[string]string
+	RootIssuer string            `json:"rootIssuer,omitempty"`
+	Issuer     string            `json:"issuer,omitempty"`
+	IssuerMap  map[string]string `json:"issuerMap,omitempty"`
+	Adoption   *Adoption         `json:"adoption,omitempty"`
+	CARotation *CARotation       `json:"caRotation,omitempty"`
+	Chain      *Chain            `json:"chain,omitempty"`
+	// NameConstraintsMap overrides NameConstraints for the CAs of the given
+	// names, like IssuerMap
+	NameConstraints    *NameConstraints           `json:"nameConstraints,omitempty"`
+	NameConstraintsMap map[string]NameConstraints `json:"nameConstraintsMap,omitempty"`
+	// TrustBundles is only read from the global configuration, as Bundles
+	// are cluster scoped
+	TrustBundles *TrustBundles `json:"trustBundles,omitempty"`
+	// ApproverPolicy is only read from the global configuration, as
+	// CertificateRequestPolicies are cluster scoped
+	ApproverPolicy *ApproverPolicy `json:"approverPolicy,omitempty"`
+	// SharedRoot is only read from the global configuration, as the root is
+	// shared by all namespaces
+	SharedRoot *SharedRoot `json:"sharedRoot,omitempty"`
+}
+
+// Adoption keeps the Secrets issued by Skupper in use once their
+// certificates are delegated to cert-manager. CA Secrets back the CA Issuer
+// as they are, so existing trust chains stay valid, and other certificates
+// are only reissued RenewBefore their adopted certificate expires.
+type Adoption struct {
+	Enabled     bool         `json:"enabled"`
+	RenewBefore *v1.Duration `json:"renewBefore,omitempty"`
+}
+
+// ConfigFile is the layout of the configuration document. The top level
+// holds the global configuration and Namespaces the per namespace overrides.
+type ConfigFile struct {
+	Config
+	Namespaces map[string]Config `json:"namespaces,omitempty"`
 }
 
 var (
+	mutex           sync.RWMutex
 	globalConfig    Config
 	namespaceConfig map[string]Config
 )
@@ -28,48 +76,381 @@ func init() {
 	//}
 }
 
-func GetRootIssuer(namespace string) (string, bool) {
-	var rootIssuer string
-	if nsConfig, ok := namespaceConfig[namespace]; ok {
-		rootIssuer = nsConfig.RootIssuer
+func ParseConfig(data []byte) (*ConfigFile, error) {
+	cfg := &ConfigFile{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
 	}
-	if rootIssuer == "" {
-		rootIssuer = globalConfig.RootIssuer
+	if tb := cfg.TrustBundles; tb != nil && tb.Scope != "" && tb.Scope != TrustBundleScopeCA && tb.Scope != TrustBundleScopeNamespace {
+		return nil, fmt.Errorf("trustBundles.scope must be %s or %s", TrustBundleScopeCA, TrustBundleScopeNamespace)
 	}
-	clusterScope := false
-	if len(rootIssuer) > 1 && rootIssuer[0] == '/' {
-		rootIssuer = rootIssuer[1:]
-		clusterScope = true
+	if err := validateChain("chain", cfg.Chain); err != nil {
+		return nil, err
+	}
+	for ns, nsConfig := range cfg.Namespaces {
+		if err := validateChain("namespaces."+ns+".chain", nsConfig.Chain); err != nil {
+			return nil, err
+		}
+	}
+	if ap := cfg.ApproverPolicy; ap != nil && ap.ServiceAccount != nil && (ap.ServiceAccount.Name == "" || ap.ServiceAccount.Namespace == "") {
+		return nil, fmt.Errorf("approverPolicy.serviceAccount requires both a name and a namespace")
+	}
+	return cfg, nil
+}
+
+// SetConfig replaces the global and per namespace configuration.
+func SetConfig(cfg *ConfigFile) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	globalConfig = cfg.Config
+	namespaceConfig = make(map[string]Config)
+	for ns, nsConfig := range cfg.Namespaces {
+		namespaceConfig[ns] = nsConfig
 	}
-	return rootIssuer, clusterScope
+}
+
+func GetRootIssuer(namespace string) (string, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	rootIssuer, _ := getRootIssuer(namespace)
+	return clusterScoped(rootIssuer)
 }
 
 func GetIssuerFor(obj *v2alpha1.Certificate) (string, bool) {
-	var issuer string
-	var clusterScope bool
-	ns := obj.Namespace
-	if nsConfig, ok := namespaceConfig[ns]; ok {
-		issuer = getIssuerFor(nsConfig, obj)
+	mutex.RLock()
+	defer mutex.RUnlock()
+	issuer, _ := getIssuerFor(obj)
+	return clusterScoped(issuer)
+}
+
+// CARotation publishes a trust bundle holding both the previous and the
+// renewed CA certificates when a CA is renewed, until the certificates it
+// signed have been reissued and GracePeriod has passed since. These are only
+// reissued PublishDelay after the renewed CA has been published, so that
+// consumers of the trust bundle have picked it up.
+type CARotation struct {
+	Enabled      bool         `json:"enabled"`
+	GracePeriod  *v1.Duration `json:"gracePeriod,omitempty"`
+	PublishDelay *v1.Duration `json:"publishDelay,omitempty"`
+}
+
+// GetCARotation returns whether CA rotations are orchestrated in the given
+// namespace, and for how long previous CAs are trusted.
+func GetCARotation(namespace string) (bool, time.Duration) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	rotation := globalConfig.CARotation
+	if nsConfig, ok := namespaceConfig[namespace]; ok && nsConfig.CARotation != nil {
+		rotation = nsConfig.CARotation
+	}
+	if rotation == nil || !rotation.Enabled {
+		return false, 0
+	}
+	if rotation.GracePeriod == nil {
+		return true, DefaultCAGracePeriod
+	}
+	return true, rotation.GracePeriod.Duration
+}
+
+// GetCAPublishDelay returns how long the certificates signed by a renewed CA
+// wait before being reissued in the given namespace.
+func GetCAPublishDelay(namespace string) time.Duration {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	rotation := globalConfig.CARotation
+	if nsConfig, ok := namespaceConfig[namespace]; ok && nsConfig.CARotation != nil {
+		rotation = nsConfig.CARotation
+	}
+	if rotation == nil || rotation.PublishDelay == nil {
+		return DefaultCAPublishDelay
+	}
+	return rotation.PublishDelay.Duration
+}
+
+// TrustBundles distributes the CA certificates through trust-manager Bundles,
+// one per CA or per namespace, to the namespaces matching TargetNamespaces,
+// or all namespaces. The certificates are written under Key in the target
+// ConfigMaps.
+type TrustBundles struct {
+	Enabled          bool              `json:"enabled"`
+	Scope            string            `json:"scope,omitempty"`
+	TargetNamespaces *v1.LabelSelector `json:"targetNamespaces,omitempty"`
+	Key              string            `json:"key,omitempty"`
+}
+
+// GetTrustBundles returns the trust-manager Bundles configuration, with the
+// defaults applied, or nil if disabled.
+func GetTrustBundles() *TrustBundles {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if globalConfig.TrustBundles == nil || !globalConfig.TrustBundles.Enabled {
+		return nil
+	}
+	config := *globalConfig.TrustBundles
+	if config.Scope == "" {
+		config.Scope = TrustBundleScopeCA
+	}
+	if config.Key == "" {
+		config.Key = DefaultTrustBundleKey
+	}
+	return &config
+}
+
+// ApproverPolicy generates an approver-policy CertificateRequestPolicy for
+// each delegated certificate, only allowing its subject and hosts, and for
+// the intermediates and the shared root CA, and lets the cert-manager
+// ServiceAccount use them.
+type ApproverPolicy struct {
+	Enabled        bool            `json:"enabled"`
+	ServiceAccount *ServiceAccount `json:"serviceAccount,omitempty"`
+}
+
+type ServiceAccount struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// GetApproverPolicy returns the approver-policy configuration, with the
+// defaults applied, or nil if disabled.
+func GetApproverPolicy() *ApproverPolicy {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if globalConfig.ApproverPolicy == nil || !globalConfig.ApproverPolicy.Enabled {
+		return nil
+	}
+	config := *globalConfig.ApproverPolicy
+	if config.ServiceAccount == nil {
+		config.ServiceAccount = &ServiceAccount{
+			Name:      DefaultCertManagerServiceAccount,
+			Namespace: DefaultCertManagerNamespace,
+		}
+	}
+	return &config
+}
+
+// SharedRoot replaces the self-signed root Issuer created in every namespace
+// with a single root CA, issued in Namespace and exposed as a ClusterIssuer
+// named Name, so that the CAs of all namespaces are intermediates of the
+// same root. ClusterIssuers read their Secret from the cert-manager cluster
+// resource namespace, which Namespace must match. It defaults to the
+// controller namespace, in which case cert-manager must be started with
+// --cluster-resource-namespace set to it, or the ClusterIssuer never gets
+// ready. The controller needs permissions on the cert-manager Issuers and
+// Certificates of Namespace, and on its Roles and RoleBindings with
+// ApproverPolicy.
+type SharedRoot struct {
+	Enabled   bool   `json:"enabled"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// GetSharedRoot returns the shared root configuration, with the defaults
+// applied, or nil if disabled. The namespace is left empty when not set.
+func GetSharedRoot() *SharedRoot {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return getSharedRoot()
+}
+
+func getSharedRoot() *SharedRoot {
+	if globalConfig.SharedRoot == nil || !globalConfig.SharedRoot.Enabled {
+		return nil
+	}
+	config := *globalConfig.SharedRoot
+	if config.Name == "" {
+		config.Name = DefaultSharedRootName
+	}
+	if config.Subject == "" {
+		config.Subject = config.Name
+	}
+	return &config
+}
+
+// Chain inserts intermediate CAs between the root issuer and the CAs of the
+// Skupper sites, in order, the first one being issued by the root issuer.
+// Each intermediate gets a CA Issuer of the same name in the namespace. As
+// the CA Issuers include the chain of their CA, the Secrets of the issued
+// certificates hold the full chain up to the root. cert-manager cannot set
+// the path length of the intermediates, so the depth of the chain is only
+// enforced when the root issuer sets it, see PathLenProblem.
+type Chain struct {
+	Intermediates []Intermediate `json:"intermediates,omitempty"`
+}
+
+type Intermediate struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject,omitempty"`
+}
+
+func validateChain(path string, chain *Chain) error {
+	if chain == nil {
+		return nil
+	}
+	if len(chain.Intermediates) > MaxChainIntermediates {
+		return fmt.Errorf("%s: at most %d intermediates are supported", path, MaxChainIntermediates)
+	}
+	names := map[string]bool{}
+	for i, intermediate := range chain.Intermediates {
+		if intermediate.Name == "" || names[intermediate.Name] {
+			return fmt.Errorf("%s.intermediates[%d]: a unique name is required", path, i)
+		}
+		names[intermediate.Name] = true
+	}
+	return nil
+}
+
+// GetChain returns the intermediate CAs configured for the given namespace.
+func GetChain(namespace string) []Intermediate {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return getChain(namespace)
+}
+
+func getChain(namespace string) []Intermediate {
+	chain := globalConfig.Chain
+	if nsConfig, ok := namespaceConfig[namespace]; ok && nsConfig.Chain != nil {
+		chain = nsConfig.Chain
+	}
+	if chain == nil {
+		return nil
+	}
+	return chain.Intermediates
+}
+
+// NameConstraints limits the names the CA certificates can sign to
+// PermittedDNSDomains or, when empty, to the hosts of the certificates each
+// CA signs, as known when the CA is issued. It requires cert-manager to run
+// with the NameConstraints feature gate enabled. With derived hosts, the CA
+// is reissued every time the hosts it signs change, keeping its key unless
+// CA rotation is enabled: PermittedDNSDomains avoids these reissues, at the
+// cost of broader constraints.
+type NameConstraints struct {
+	Enabled             bool     `json:"enabled"`
+	Critical            bool     `json:"critical,omitempty"`
+	PermittedDNSDomains []string `json:"permittedDNSDomains,omitempty"`
+}
+
+// GetNameConstraints returns the name constraints of the given CA, looked up
+// in the namespace and then the global configuration, or nil if disabled.
+func GetNameConstraints(obj *v2alpha1.Certificate) *NameConstraints {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	configs := []Config{globalConfig}
+	if nsConfig, ok := namespaceConfig[obj.Namespace]; ok {
+		configs = []Config{nsConfig, globalConfig}
+	}
+	for _, config := range configs {
+		if constraints, ok := config.NameConstraintsMap[obj.Name]; ok {
+			return enabledConstraints(constraints)
+		}
+		if config.NameConstraints != nil {
+			return enabledConstraints(*config.NameConstraints)
+		}
+	}
+	return nil
+}
+
+func enabledConstraints(constraints NameConstraints) *NameConstraints {
+	if !constraints.Enabled {
+		return nil
+	}
+	return &constraints
+}
+
+// GetAdoption returns whether Secrets issued by Skupper are adopted in the
+// given namespace, and how long before their expiry they are reissued.
+func GetAdoption(namespace string) (bool, time.Duration) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	adoption := globalConfig.Adoption
+	if nsConfig, ok := namespaceConfig[namespace]; ok && nsConfig.Adoption != nil {
+		adoption = nsConfig.Adoption
+	}
+	if adoption == nil || !adoption.Enabled {
+		return false, 0
+	}
+	if adoption.RenewBefore == nil {
+		return true, DefaultAdoptionRenewBefore
+	}
+	return true, adoption.RenewBefore.Duration
+}
+
+// Resolution is the issuer resolved for a Skupper Certificate, along with
+// the configuration entry it was resolved from.
+type Resolution struct {
+	Name          string
+	ClusterIssuer bool
+	Source        string
+}
+
+// ResolveIssuer resolves the issuer of the cert-manager Certificate
+// generated for obj. CA certificates fall back to the root issuer and all
+// others to the issuer named after their CA.
+func ResolveIssuer(obj *v2alpha1.Certificate) Resolution {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	issuer, source := getIssuerFor(obj)
+	if chain := getChain(obj.Namespace); issuer == "" && obj.Spec.Signing && len(chain) > 0 {
+		issuer, source = chain[len(chain)-1].Name, fmt.Sprintf("chain.intermediates[%d]", len(chain)-1)
+	}
+	if issuer == "" && obj.Spec.Signing {
+		issuer, source = getRootIssuer(obj.Namespace)
 	}
 	if issuer == "" {
-		issuer = getIssuerFor(globalConfig, obj)
+		if obj.Spec.Signing {
+			issuer, source = DefaultRootIssuerName, "default root issuer"
+		} else {
+			issuer, source = obj.Spec.Ca, "spec.ca"
+		}
 	}
-	if len(issuer) > 1 && issuer[0] == '/' {
-		issuer = issuer[1:]
-		clusterScope = true
+	name, clusterIssuer := clusterScoped(issuer)
+	return Resolution{
+		Name:          name,
+		ClusterIssuer: clusterIssuer,
+		Source:        source,
 	}
-	return issuer, clusterScope
 }
 
-func getIssuerFor(config Config, obj *v2alpha1.Certificate) string {
+func getRootIssuer(namespace string) (string, string) {
+	if nsConfig, ok := namespaceConfig[namespace]; ok && nsConfig.RootIssuer != "" {
+		return nsConfig.RootIssuer, "namespaces." + namespace + ".rootIssuer"
+	}
+	if globalConfig.RootIssuer != "" {
+		return globalConfig.RootIssuer, "rootIssuer"
+	}
+	if sharedRoot := getSharedRoot(); sharedRoot != nil {
+		return "/" + sharedRoot.Name, "sharedRoot"
+	}
+	return "", ""
+}
+
+func getIssuerFor(obj *v2alpha1.Certificate) (string, string) {
+	if nsConfig, ok := namespaceConfig[obj.Namespace]; ok {
+		if issuer, source := getConfigIssuerFor(nsConfig, obj); issuer != "" {
+			return issuer, "namespaces." + obj.Namespace + "." + source
+		}
+	}
+	return getConfigIssuerFor(globalConfig, obj)
+}
+
+func getConfigIssuerFor(config Config, obj *v2alpha1.Certificate) (string, string) {
 	ca := obj.Spec.Ca
 	for from, to := range config.IssuerMap {
 		if ca == from {
-			return to
+			return to, "issuerMap." + from
 		}
 	}
 	if config.Issuer != "" {
-		return config.Issuer
+		return config.Issuer, "issuer"
+	}
+	return "", ""
+}
+
+// clusterScoped strips the leading slash identifying ClusterIssuers.
+func clusterScoped(issuer string) (string, bool) {
+	if len(issuer) > 1 && issuer[0] == '/' {
+		return issuer[1:], true
 	}
-	return ""
+	return issuer, false
 }
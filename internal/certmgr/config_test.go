@@ -0,0 +1,87 @@
+package certmgr
+
+import (
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveIssuer(t *testing.T) {
+	t.Cleanup(func() { SetConfig(&ConfigFile{}) })
+	ca := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-ca", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Signing: true},
+	}
+	leaf := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Ca: "skupper-site-ca"},
+	}
+	otherNs := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns2"},
+		Spec:       v2alpha1.CertificateSpec{Ca: "skupper-site-ca"},
+	}
+	config := &ConfigFile{
+		Config: Config{
+			RootIssuer: "/global-root",
+			IssuerMap:  map[string]string{"skupper-site-ca": "mapped"},
+		},
+		Namespaces: map[string]Config{
+			"ns1": {Issuer: "ns1-issuer"},
+		},
+	}
+	tests := []struct {
+		name     string
+		config   *ConfigFile
+		obj      *v2alpha1.Certificate
+		expected Resolution
+	}{
+		{"default ca", &ConfigFile{}, ca, Resolution{Name: DefaultRootIssuerName, Source: "default root issuer"}},
+		{"default leaf", &ConfigFile{}, leaf, Resolution{Name: "skupper-site-ca", Source: "spec.ca"}},
+		{"namespace issuer", config, leaf, Resolution{Name: "ns1-issuer", Source: "namespaces.ns1.issuer"}},
+		{"namespace issuer for ca", config, ca, Resolution{Name: "ns1-issuer", Source: "namespaces.ns1.issuer"}},
+		{"global mapping", config, otherNs, Resolution{Name: "mapped", Source: "issuerMap.skupper-site-ca"}},
+		{"global root", &ConfigFile{Config: Config{RootIssuer: "/global-root"}}, ca, Resolution{Name: "global-root", ClusterIssuer: true, Source: "rootIssuer"}},
+		{"shared root", &ConfigFile{Config: Config{SharedRoot: &SharedRoot{Enabled: true}}}, ca, Resolution{Name: DefaultSharedRootName, ClusterIssuer: true, Source: "sharedRoot"}},
+		{"chain", &ConfigFile{Config: Config{Chain: &Chain{Intermediates: []Intermediate{{Name: "org"}, {Name: "unit"}}}}}, ca, Resolution{Name: "unit", Source: "chain.intermediates[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetConfig(tt.config)
+			if got := ResolveIssuer(tt.obj); got != tt.expected {
+				t.Errorf("resolved %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		invalid bool
+		check   func(*ConfigFile) bool
+	}{
+		{name: "unknown field", data: "issuer: a\nunknown: b\n", invalid: true},
+		{name: "namespace override", data: "issuer: a\nnamespaces:\n  ns1:\n    rootIssuer: /root\n", check: func(cfg *ConfigFile) bool {
+			return cfg.Issuer == "a" && cfg.Namespaces["ns1"].RootIssuer == "/root"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(tt.data))
+			if tt.invalid {
+				if err == nil {
+					t.Error("invalid configuration accepted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("unexpected configuration %+v", cfg)
+			}
+		})
+	}
+}
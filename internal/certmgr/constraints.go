@@ -0,0 +1,47 @@
+package certmgr
+
+import (
+	"net"
+	"slices"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+)
+
+// NewNameConstraints returns the name constraints of the given CA, from the
+// configured domains or else the hosts of the certificates it signs, or nil
+// if disabled or there is nothing to permit. IP addresses are permitted as
+// single address ranges.
+func NewNameConstraints(obj *v2alpha1.Certificate, signedHosts []string) *cm.NameConstraints {
+	config := GetNameConstraints(obj)
+	if config == nil {
+		return nil
+	}
+	names := config.PermittedDNSDomains
+	if len(names) == 0 {
+		names = signedHosts
+	}
+	permitted := &cm.NameConstraintItem{}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			permitted.IPRanges = append(permitted.IPRanges, (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String())
+		} else {
+			permitted.DNSDomains = append(permitted.DNSDomains, name)
+		}
+	}
+	if len(permitted.DNSDomains) == 0 && len(permitted.IPRanges) == 0 {
+		return nil
+	}
+	slices.Sort(permitted.DNSDomains)
+	permitted.DNSDomains = slices.Compact(permitted.DNSDomains)
+	slices.Sort(permitted.IPRanges)
+	permitted.IPRanges = slices.Compact(permitted.IPRanges)
+	return &cm.NameConstraints{
+		Critical:  config.Critical,
+		Permitted: permitted,
+	}
+}
@@ -0,0 +1,133 @@
+package certmgr
+
+import (
+	"slices"
+	"testing"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewNameConstraints(t *testing.T) {
+	t.Cleanup(func() { SetConfig(&ConfigFile{}) })
+	ca := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-ca", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Signing: true},
+	}
+	hosts := []string{"b.example.com", "10.0.0.1", "a.example.com", "b.example.com", "::1"}
+	tests := []struct {
+		name     string
+		config   Config
+		critical bool
+		dns      []string
+		ips      []string
+	}{
+		{name: "disabled"},
+		{
+			name:   "derived from hosts",
+			config: Config{NameConstraints: &NameConstraints{Enabled: true}},
+			dns:    []string{"a.example.com", "b.example.com"},
+			ips:    []string{"10.0.0.1/32", "::1/128"},
+		},
+		{
+			name:     "permitted domains",
+			config:   Config{NameConstraints: &NameConstraints{Enabled: true, Critical: true, PermittedDNSDomains: []string{"example.com"}}},
+			critical: true,
+			dns:      []string{"example.com"},
+		},
+		{
+			name: "disabled for the CA",
+			config: Config{
+				NameConstraints:    &NameConstraints{Enabled: true},
+				NameConstraintsMap: map[string]NameConstraints{"skupper-site-ca": {}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetConfig(&ConfigFile{Config: test.config})
+			constraints := NewNameConstraints(ca, hosts)
+			if test.dns == nil && test.ips == nil {
+				if constraints != nil {
+					t.Fatalf("unexpected constraints %+v", constraints)
+				}
+				return
+			}
+			if constraints == nil || constraints.Permitted == nil {
+				t.Fatal("constraints missing")
+			}
+			if constraints.Critical != test.critical {
+				t.Errorf("critical = %v, want %v", constraints.Critical, test.critical)
+			}
+			if !slices.Equal(constraints.Permitted.DNSDomains, test.dns) {
+				t.Errorf("DNS domains = %v, want %v", constraints.Permitted.DNSDomains, test.dns)
+			}
+			if !slices.Equal(constraints.Permitted.IPRanges, test.ips) {
+				t.Errorf("IP ranges = %v, want %v", constraints.Permitted.IPRanges, test.ips)
+			}
+		})
+	}
+	SetConfig(&ConfigFile{Config: Config{NameConstraints: &NameConstraints{Enabled: true}}})
+	if constraints := NewNameConstraints(ca, nil); constraints != nil {
+		t.Errorf("constraints without hosts %+v", constraints)
+	}
+}
+
+func TestCACertificateKeyRotation(t *testing.T) {
+	t.Cleanup(func() { SetConfig(&ConfigFile{}) })
+	ca := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-ca", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Signing: true},
+	}
+
+	SetConfig(&ConfigFile{})
+	pinned := NewCACertificate(ca, nil)
+	if pinned.Spec.PrivateKey == nil || pinned.Spec.PrivateKey.RotationPolicy != cm.RotationPolicyNever {
+		t.Errorf("CA key not kept without CA rotation: %+v", pinned.Spec.PrivateKey)
+	}
+
+	SetConfig(&ConfigFile{Config: Config{CARotation: &CARotation{Enabled: true}}})
+	rotated := NewCACertificate(ca, nil)
+	if rotated.Spec.PrivateKey != nil {
+		t.Errorf("CA key kept with CA rotation: %+v", rotated.Spec.PrivateKey)
+	}
+	if SpecHashOf(pinned) == SpecHashOf(rotated) {
+		t.Error("key rotation policy not covered by the spec hash")
+	}
+}
+
+// TestCACertificateSpecHash shows that, with name constraints derived from
+// the signed hosts, editing the hosts of a leaf changes the spec hash of its
+// CA, which is then reissued, unless permitted domains are configured.
+func TestCACertificateSpecHash(t *testing.T) {
+	t.Cleanup(func() { SetConfig(&ConfigFile{}) })
+	ca := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-ca", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Signing: true},
+	}
+	derived := Config{NameConstraints: &NameConstraints{Enabled: true}}
+	permitted := Config{NameConstraints: &NameConstraints{Enabled: true, PermittedDNSDomains: []string{"example.com"}}}
+	tests := []struct {
+		name        string
+		config      Config
+		hosts       []string
+		editedHosts []string
+		changed     bool
+	}{
+		{name: "leaf host added", config: derived, hosts: []string{"a.example.com"}, editedHosts: []string{"a.example.com", "b.example.com"}, changed: true},
+		{name: "leaf hosts reordered", config: derived, hosts: []string{"a.example.com", "b.example.com"}, editedHosts: []string{"b.example.com", "a.example.com"}},
+		{name: "leaf host added with permitted domains", config: permitted, hosts: []string{"a.example.com"}, editedHosts: []string{"a.example.com", "b.example.com"}},
+		{name: "leaf host added without constraints", hosts: []string{"a.example.com"}, editedHosts: []string{"a.example.com", "b.example.com"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetConfig(&ConfigFile{Config: test.config})
+			before := NewCACertificate(ca, NewNameConstraints(ca, test.hosts))
+			after := NewCACertificate(ca, NewNameConstraints(ca, test.editedHosts))
+			if changed := SpecHashOf(before) != SpecHashOf(after); changed != test.changed {
+				t.Errorf("spec hash changed = %v, want %v", changed, test.changed)
+			}
+		})
+	}
+}
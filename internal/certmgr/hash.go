@@ -0,0 +1,108 @@
+package certmgr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v2 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	SpecHashAnnotation = "skupper.io/cert-manager-spec-hash"
+)
+
+// specHashInput holds everything that affects the generated cert-manager
+// resources. Skupper settings unrelated to issuance are left out on purpose.
+type specHashInput struct {
+	Ca        string             `json:"ca"`
+	Subject   string             `json:"subject"`
+	Hosts     []string           `json:"hosts"`
+	Client    bool               `json:"client"`
+	Server    bool               `json:"server"`
+	Signing   bool               `json:"signing"`
+	IssuerRef v2.ObjectReference `json:"issuerRef"`
+	Config    specHashConfig     `json:"config"`
+	// PrivateKey and NameConstraints are only set for CAs, see caSpecHash
+	PrivateKey      *cm.CertificatePrivateKey `json:"privateKey,omitempty"`
+	NameConstraints *cm.NameConstraints       `json:"nameConstraints,omitempty"`
+}
+
+type specHashConfig struct {
+	RootIssuer string `json:"rootIssuer"`
+	Duration   string `json:"duration"`
+}
+
+// SpecHash returns a canonical digest of the inputs the cert-manager resources
+// for the given Skupper Certificate are generated from: the relevant subset of
+// its spec, the resolved issuer and the effective configuration.
+func SpecHash(obj *v2alpha1.Certificate) string {
+	return hashOf(specHashInputFor(obj))
+}
+
+// caSpecHash returns the spec hash of the CA certificate generated for obj,
+// also covering its key rotation policy, which depends on the CA rotation
+// configuration, and its name constraints, which may be derived from the
+// hosts of the certificates it signs.
+func caSpecHash(obj *v2alpha1.Certificate, spec cm.CertificateSpec) string {
+	input := specHashInputFor(obj)
+	input.PrivateKey = spec.PrivateKey
+	input.NameConstraints = spec.NameConstraints
+	return hashOf(input)
+}
+
+func specHashInputFor(obj *v2alpha1.Certificate) specHashInput {
+	hosts := slices.Clone(obj.Spec.Hosts)
+	slices.Sort(hosts)
+	rootIssuer, _ := GetRootIssuer(obj.Namespace)
+	return specHashInput{
+		Ca:        obj.Spec.Ca,
+		Subject:   obj.Spec.Subject,
+		Hosts:     hosts,
+		Client:    obj.Spec.Client,
+		Server:    obj.Spec.Server,
+		Signing:   obj.Spec.Signing,
+		IssuerRef: issuerRefFor(obj),
+		Config: specHashConfig{
+			RootIssuer: rootIssuer,
+			Duration:   DefaultExpiration().String(),
+		},
+	}
+}
+
+func hashOf(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// clusterScopedName returns the name of a cluster scoped resource generated
+// for the given namespace and names. A hash of the parts is appended, as
+// joining them with dashes alone is ambiguous: namespace "a-b" and name "c"
+// would collide with namespace "a" and name "b-c".
+func clusterScopedName(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	name := "skupper-" + strings.Join(parts, "-")
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], "-.")
+	}
+	return name + suffix
+}
+
+// SpecHashOf returns the spec hash recorded on a generated resource.
+func SpecHashOf(obj v1.Object) string {
+	return obj.GetAnnotations()[SpecHashAnnotation]
+}
+
+func specHashAnnotations(obj *v2alpha1.Certificate) map[string]string {
+	return map[string]string{
+		SpecHashAnnotation: SpecHash(obj),
+	}
+}
@@ -0,0 +1,123 @@
+package certmgr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestSpecHash(t *testing.T) {
+	t.Cleanup(func() { globalConfig = Config{} })
+	base := v2alpha1.CertificateSpec{
+		Ca:      "skupper-site-ca",
+		Subject: "skupper-router",
+		Hosts:   []string{"a.example.com", "b.example.com"},
+		Server:  true,
+	}
+	tests := []struct {
+		name    string
+		edit    func(spec *v2alpha1.CertificateSpec)
+		config  Config
+		changed bool
+	}{
+		{name: "hosts reordered", edit: func(spec *v2alpha1.CertificateSpec) { spec.Hosts = []string{"b.example.com", "a.example.com"} }},
+		{name: "unrelated settings", edit: func(spec *v2alpha1.CertificateSpec) { spec.Settings = map[string]string{"other": "value"} }},
+		{name: "subject", edit: func(spec *v2alpha1.CertificateSpec) { spec.Subject = "other" }, changed: true},
+		{name: "host removed", edit: func(spec *v2alpha1.CertificateSpec) { spec.Hosts = []string{"a.example.com"} }, changed: true},
+		{name: "ca", edit: func(spec *v2alpha1.CertificateSpec) { spec.Ca = "other-ca" }, changed: true},
+		{name: "client usage", edit: func(spec *v2alpha1.CertificateSpec) { spec.Client = true }, changed: true},
+		{name: "issuer configured", config: Config{Issuer: "/cluster-issuer"}, changed: true},
+		{name: "root issuer configured", config: Config{RootIssuer: "root"}, changed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalConfig = Config{}
+			before := SpecHash(&v2alpha1.Certificate{
+				ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+				Spec:       base,
+			})
+			spec := base
+			if tt.edit != nil {
+				tt.edit(&spec)
+			}
+			globalConfig = tt.config
+			after := SpecHash(&v2alpha1.Certificate{
+				ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+				Spec:       spec,
+			})
+			if changed := before != after; changed != tt.changed {
+				t.Errorf("hash changed = %v, expected %v", changed, tt.changed)
+			}
+		})
+	}
+}
+
+func TestSpecHashAnnotations(t *testing.T) {
+	obj := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Ca: "skupper-site-ca", Subject: "skupper-router"},
+	}
+	tests := []struct {
+		name string
+		obj  v1.Object
+	}{
+		{name: "certificate", obj: NewCertificate(obj)},
+		{name: "issuer", obj: NewIssuer(obj)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpecHashOf(tt.obj); got != SpecHash(obj) {
+				t.Errorf("recorded hash %q, expected %q", got, SpecHash(obj))
+			}
+		})
+	}
+}
+
+func TestHashOf(t *testing.T) {
+	hash := hashOf(map[string]any{"a": []string{"x"}, "b": 1})
+	if len(hash) != 64 {
+		t.Fatalf("hash of %d characters, expected a hex encoded sha256", len(hash))
+	}
+	tests := []struct {
+		name string
+		v    any
+		same bool
+	}{
+		{name: "keys reordered", v: map[string]any{"b": 1, "a": []string{"x"}}, same: true},
+		{name: "value changed", v: map[string]any{"a": []string{"y"}, "b": 1}},
+		{name: "key added", v: map[string]any{"a": []string{"x"}, "b": 1, "c": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := hashOf(tt.v) == hash; same != tt.same {
+				t.Errorf("same hash = %v, expected %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestClusterScopedName(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{name: "dashes moved between namespace and name", a: clusterScopedName("a-b", "c"), b: clusterScopedName("a", "b-c")},
+		{name: "namespace and CA bundles", a: BundleName(TrustBundleScopeNamespace, "a-b", ""), b: BundleName(TrustBundleScopeCA, "a", "b")},
+		{name: "same parts", a: clusterScopedName("ns", "name"), b: clusterScopedName("ns", "name"), same: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := tt.a == tt.b; same != tt.same {
+				t.Errorf("%q and %q same = %v, expected %v", tt.a, tt.b, same, tt.same)
+			}
+		})
+	}
+	long := clusterScopedName("ns", strings.Repeat("x", 300))
+	if errs := validation.IsDNS1123Subdomain(long); len(errs) > 0 {
+		t.Errorf("invalid name of %d characters: %v", len(long), errs)
+	}
+}
@@ -0,0 +1,168 @@
+package certmgr
+
+import (
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v2 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// PolicyRoleName is the name of the Role, and RoleBinding, letting the
+	// cert-manager ServiceAccount use the policies of a namespace.
+	PolicyRoleName = "skupper-cert-manager-approver-policy"
+	// SharedRootPolicyRoleName is the name of the Role, and RoleBinding,
+	// letting it use the policy of the shared root in its namespace, which
+	// may hold Skupper Certificates too.
+	SharedRootPolicyRoleName = "skupper-cert-manager-shared-root-approver-policy"
+)
+
+// PolicyGVR identifies the approver-policy CertificateRequestPolicy
+// resource, used through the dynamic client to avoid depending on
+// approver-policy.
+var PolicyGVR = schema.GroupVersionResource{
+	Group:    "policy.cert-manager.io",
+	Version:  "v1alpha1",
+	Resource: "certificaterequestpolicies",
+}
+
+// PolicyName returns the name of the CertificateRequestPolicy generated for
+// the given Skupper Certificate.
+func PolicyName(obj *v2alpha1.Certificate) string {
+	return clusterScopedName(obj.Namespace, obj.Name)
+}
+
+// IntermediatePolicyName returns the name of the CertificateRequestPolicy
+// generated for an intermediate CA of the namespace.
+func IntermediatePolicyName(namespace string, intermediate Intermediate) string {
+	return clusterScopedName(namespace, "intermediate", intermediate.Name)
+}
+
+// SharedRootPolicyName returns the name of the CertificateRequestPolicy
+// generated for the shared root CA.
+func SharedRootPolicyName(config SharedRoot) string {
+	return clusterScopedName("shared-root", config.Name)
+}
+
+// NewCertificateRequestPolicy returns a policy only allowing the requests of
+// the cert-manager Certificate generated for obj: its subject, its hosts and
+// the default usages, from the issuer resolved for it in its namespace.
+// Policies cannot select requests by Certificate, so a policy is generated
+// per certificate to keep each subject tied to its own hosts.
+func NewCertificateRequestPolicy(obj *v2alpha1.Certificate) *unstructured.Unstructured {
+	return newRequestPolicy(PolicyName(obj), obj.Namespace, issuerRefFor(obj), obj.Spec.Subject, obj.Spec.Hosts, obj.Spec.Signing)
+}
+
+// NewIntermediatePolicy returns the policy allowing the requests of the
+// intermediate CA at the given index of the chain of the namespace.
+func NewIntermediatePolicy(namespace string, chain []Intermediate, index int) *unstructured.Unstructured {
+	cmCert := NewIntermediateCertificate(namespace, chain, index)
+	return newRequestPolicy(IntermediatePolicyName(namespace, chain[index]), namespace, cmCert.Spec.IssuerRef, cmCert.Spec.CommonName, nil, true)
+}
+
+// NewSharedRootPolicy returns the policy allowing the requests of the shared
+// root CA, issued in the given namespace.
+func NewSharedRootPolicy(config SharedRoot, namespace string) *unstructured.Unstructured {
+	cmCert := NewSharedRootCertificate(config, namespace)
+	return newRequestPolicy(SharedRootPolicyName(config), namespace, cmCert.Spec.IssuerRef, cmCert.Spec.CommonName, nil, true)
+}
+
+func newRequestPolicy(name, namespace string, ref v2.ObjectReference, subject string, hosts []string, isCA bool) *unstructured.Unstructured {
+	kind := ref.Kind
+	if kind == "" {
+		kind = cm.IssuerKind
+	}
+	usages := []any{string(cm.UsageDigitalSignature), string(cm.UsageKeyEncipherment)}
+	if isCA {
+		usages = append(usages, string(cm.UsageCertSign))
+	}
+	allowed := map[string]any{
+		"commonName": map[string]any{
+			"value":    subject,
+			"required": true,
+		},
+		"isCA":   isCA,
+		"usages": usages,
+	}
+	if len(hosts) > 0 {
+		values := make([]any, 0, len(hosts))
+		for _, host := range hosts {
+			values = append(values, host)
+		}
+		allowed["dnsNames"] = map[string]any{"values": values}
+	}
+	policy := &unstructured.Unstructured{
+		Object: map[string]any{
+			"spec": map[string]any{
+				"allowed": allowed,
+				"selector": map[string]any{
+					"issuerRef": map[string]any{
+						"name":  ref.Name,
+						"kind":  kind,
+						"group": cm.SchemeGroupVersion.Group,
+					},
+					"namespace": map[string]any{
+						"matchNames": []any{namespace},
+					},
+				},
+			},
+		},
+	}
+	policy.SetAPIVersion(PolicyGVR.GroupVersion().String())
+	policy.SetKind("CertificateRequestPolicy")
+	policy.SetName(name)
+	policy.SetLabels(map[string]string{NamespaceLabel: namespace})
+	policy.SetAnnotations(map[string]string{SpecHashAnnotation: hashOf(policy.Object["spec"])})
+	return policy
+}
+
+// NewPolicyRole returns the Role allowing the use of the given policies,
+// which approver-policy requires from the requester, cert-manager itself.
+func NewPolicyRole(name, namespace string, policies []string) *rbacv1.Role {
+	role := &rbacv1.Role{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{PolicyGVR.Group},
+			Resources:     []string{PolicyGVR.Resource},
+			Verbs:         []string{"use"},
+			ResourceNames: policies,
+		}},
+	}
+	role.Annotations = map[string]string{SpecHashAnnotation: hashOf(role.Rules)}
+	return role
+}
+
+func NewPolicyRoleBinding(name, namespace string, sa ServiceAccount) *rbacv1.RoleBinding {
+	subjects := []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      sa.Name,
+		Namespace: sa.Namespace,
+	}}
+	return &rbacv1.RoleBinding{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{SpecHashAnnotation: hashOf(subjects)},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: subjects,
+	}
+}
@@ -0,0 +1,93 @@
+package certmgr
+
+import (
+	"slices"
+	"testing"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// policyAllows tells whether approver-policy would approve the requests of
+// cmCert with the given policy, for the fields the policies restrict.
+func policyAllows(policy *unstructured.Unstructured, cmCert *cm.Certificate) bool {
+	ref := cmCert.Spec.IssuerRef
+	kind := ref.Kind
+	if kind == "" {
+		kind = cm.IssuerKind
+	}
+	issuerRef, _, _ := unstructured.NestedStringMap(policy.Object, "spec", "selector", "issuerRef")
+	if issuerRef["name"] != ref.Name || issuerRef["kind"] != kind {
+		return false
+	}
+	namespaces, _, _ := unstructured.NestedStringSlice(policy.Object, "spec", "selector", "namespace", "matchNames")
+	if !slices.Contains(namespaces, cmCert.Namespace) {
+		return false
+	}
+	commonName, _, _ := unstructured.NestedString(policy.Object, "spec", "allowed", "commonName", "value")
+	isCA, _, _ := unstructured.NestedBool(policy.Object, "spec", "allowed", "isCA")
+	if commonName != cmCert.Spec.CommonName || isCA != cmCert.Spec.IsCA {
+		return false
+	}
+	dnsNames, _, _ := unstructured.NestedStringSlice(policy.Object, "spec", "allowed", "dnsNames", "values")
+	for _, name := range cmCert.Spec.DNSNames {
+		if !slices.Contains(dnsNames, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPoliciesCoverGeneratedCertificates(t *testing.T) {
+	t.Cleanup(func() { SetConfig(&ConfigFile{}) })
+	ca := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-ca", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Subject: "skupper-site-ca", Signing: true},
+	}
+	leaf := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Subject: "skupper-router", Ca: "skupper-site-ca", Hosts: []string{"a.example.com", "b.example.com"}},
+	}
+	chain := &Chain{Intermediates: []Intermediate{{Name: "region"}, {Name: "zone", Subject: "Zone CA"}}}
+	sharedRoot := &SharedRoot{Enabled: true, Namespace: "skupper-root"}
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "default root"},
+		{name: "chain", config: Config{Chain: chain}},
+		{name: "shared root", config: Config{SharedRoot: sharedRoot}},
+		{name: "chain under shared root", config: Config{Chain: chain, SharedRoot: sharedRoot}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.ApproverPolicy = &ApproverPolicy{Enabled: true}
+			SetConfig(&ConfigFile{Config: test.config})
+			certs := []*cm.Certificate{NewCACertificate(ca, nil), NewCertificate(leaf)}
+			policies := []*unstructured.Unstructured{NewCertificateRequestPolicy(ca), NewCertificateRequestPolicy(leaf)}
+			chain := GetChain("ns1")
+			for i := range chain {
+				certs = append(certs, NewIntermediateCertificate("ns1", chain, i))
+				policies = append(policies, NewIntermediatePolicy("ns1", chain, i))
+			}
+			if config := GetSharedRoot(); config != nil {
+				certs = append(certs, NewSharedRootCertificate(*config, config.Namespace))
+				policies = append(policies, NewSharedRootPolicy(*config, config.Namespace))
+			}
+			for _, cmCert := range certs {
+				if !slices.ContainsFunc(policies, func(policy *unstructured.Unstructured) bool { return policyAllows(policy, cmCert) }) {
+					t.Errorf("no policy allows Certificate %s/%s issued by %s %q", cmCert.Namespace, cmCert.Name, cmCert.Spec.IssuerRef.Kind, cmCert.Spec.IssuerRef.Name)
+				}
+			}
+			names := map[string]bool{}
+			for _, policy := range policies {
+				if names[policy.GetName()] {
+					t.Errorf("policy name %s generated twice", policy.GetName())
+				}
+				names[policy.GetName()] = true
+			}
+		})
+	}
+}
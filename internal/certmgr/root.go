@@ -0,0 +1,70 @@
+package certmgr
+
+import (
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v2 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SharedRootSelfSignedName returns the name of the self-signed Issuer the
+// shared root CA is issued from.
+func SharedRootSelfSignedName(config SharedRoot) string {
+	return config.Name + "-selfsigned"
+}
+
+// NewSharedRootIssuer returns the self-signed Issuer of the shared root CA,
+// only usable in the namespace the root key lives in.
+func NewSharedRootIssuer(config SharedRoot, namespace string) *cm.Issuer {
+	issuer := NewRootIssuer(namespace)
+	issuer.Name = SharedRootSelfSignedName(config)
+	return issuer
+}
+
+func NewSharedRootCertificate(config SharedRoot, namespace string) *cm.Certificate {
+	cmCert := &cm.Certificate{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Certificate",
+			APIVersion: cm.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: namespace,
+		},
+		Spec: cm.CertificateSpec{
+			CommonName: config.Subject,
+			Duration: &v1.Duration{
+				Duration: DefaultExpiration(),
+			},
+			SecretName: config.Name,
+			IssuerRef: v2.ObjectReference{
+				Name: SharedRootSelfSignedName(config),
+			},
+			IsCA: true,
+		},
+	}
+	cmCert.Annotations = map[string]string{SpecHashAnnotation: hashOf(cmCert.Spec)}
+	return cmCert
+}
+
+// NewSharedRootClusterIssuer returns the ClusterIssuer signing the CAs of
+// all namespaces with the shared root CA.
+func NewSharedRootClusterIssuer(config SharedRoot) *cm.ClusterIssuer {
+	issuer := &cm.ClusterIssuer{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "ClusterIssuer",
+			APIVersion: cm.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: cm.IssuerSpec{
+			IssuerConfig: cm.IssuerConfig{
+				CA: &cm.CAIssuer{
+					SecretName: config.Name,
+				},
+			},
+		},
+	}
+	issuer.Annotations = map[string]string{SpecHashAnnotation: hashOf(issuer.Spec)}
+	return issuer
+}
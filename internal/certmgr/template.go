@@ -9,6 +9,21 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	ControllerKey  = "certificate-controller"
+	ControllerName = "cert-manager"
+
+	// NamespaceLabel is set on the cluster scoped resources generated, which
+	// cannot be owned by Skupper Certificates, holding their namespace.
+	NamespaceLabel = "skupper.io/cert-manager-namespace"
+)
+
+// IsManaged reports whether the given Skupper Certificate has been delegated
+// to cert-manager through its settings.
+func IsManaged(obj *v2alpha1.Certificate) bool {
+	return obj.Spec.Settings[ControllerKey] == ControllerName
+}
+
 func DefaultExpiration() time.Duration {
 	duration := time.Duration(5*365*24) * time.Hour
 	return duration
@@ -33,13 +48,9 @@ func NewRootIssuer(namespace string) *cm.Issuer {
 	return issuer
 }
 
-func NewCACertificate(obj *v2alpha1.Certificate) *cm.Certificate {
-	var issuer string
-	var clusterIssuer bool
-	issuer, clusterIssuer = GetIssuerFor(obj)
-	if issuer == "" {
-		issuer, clusterIssuer = GetRootIssuer(obj.Namespace)
-	}
+// NewCACertificate returns the CA certificate of obj, with the given name
+// constraints, see NewNameConstraints.
+func NewCACertificate(obj *v2alpha1.Certificate, constraints *cm.NameConstraints) *cm.Certificate {
 	var cmCert = &cm.Certificate{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "Certificate",
@@ -57,17 +68,22 @@ func NewCACertificate(obj *v2alpha1.Certificate) *cm.Certificate {
 			Duration: &v1.Duration{
 				Duration: DefaultExpiration(),
 			},
-			DNSNames:   obj.Spec.Hosts,
-			SecretName: obj.Name,
-			IssuerRef: v2.ObjectReference{
-				Name: valueOrDefault(issuer, DefaultRootIssuerName),
-			},
-			IsCA: true,
+			DNSNames:        obj.Spec.Hosts,
+			SecretName:      obj.Name,
+			IssuerRef:       issuerRefFor(obj),
+			IsCA:            true,
+			NameConstraints: constraints,
 		},
 	}
-	if clusterIssuer {
-		cmCert.Spec.IssuerRef.Kind = "ClusterIssuer"
+	if enabled, _ := GetCARotation(obj.Namespace); !enabled {
+		// cert-manager generates a new key on every issuance by default, which
+		// would invalidate the certificates signed by the CA, and CAs are
+		// reissued whenever the name constraints derived from the hosts they
+		// sign change. Without a CA rotation to reissue these certificates,
+		// the key is kept.
+		cmCert.Spec.PrivateKey = &cm.CertificatePrivateKey{RotationPolicy: cm.RotationPolicyNever}
 	}
+	cmCert.Annotations = map[string]string{SpecHashAnnotation: caSpecHash(obj, cmCert.Spec)}
 	return cmCert
 }
 
@@ -78,8 +94,9 @@ func NewIssuer(obj *v2alpha1.Certificate) *cm.Issuer {
 			APIVersion: cm.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:      obj.Name,
-			Namespace: obj.Namespace,
+			Name:        obj.Name,
+			Namespace:   obj.Namespace,
+			Annotations: specHashAnnotations(obj),
 			OwnerReferences: []v1.OwnerReference{
 				*v1.NewControllerRef(obj, v2alpha1.SchemeGroupVersion.WithKind("Certificate")),
 			},
@@ -96,15 +113,15 @@ func NewIssuer(obj *v2alpha1.Certificate) *cm.Issuer {
 }
 
 func NewCertificate(obj *v2alpha1.Certificate) *cm.Certificate {
-	issuer, clusterIssuer := GetIssuerFor(obj)
 	var cmCert = &cm.Certificate{
 		TypeMeta: v1.TypeMeta{
 			Kind:       "Certificate",
 			APIVersion: cm.SchemeGroupVersion.String(),
 		},
 		ObjectMeta: v1.ObjectMeta{
-			Name:      obj.Name,
-			Namespace: obj.Namespace,
+			Name:        obj.Name,
+			Namespace:   obj.Namespace,
+			Annotations: specHashAnnotations(obj),
 			OwnerReferences: []v1.OwnerReference{
 				*v1.NewControllerRef(obj, v2alpha1.SchemeGroupVersion.WithKind("Certificate")),
 			},
@@ -116,20 +133,27 @@ func NewCertificate(obj *v2alpha1.Certificate) *cm.Certificate {
 			},
 			DNSNames:   obj.Spec.Hosts,
 			SecretName: obj.Name,
-			IssuerRef: v2.ObjectReference{
-				Name: valueOrDefault(issuer, obj.Spec.Ca),
-			},
+			IssuerRef:  issuerRefFor(obj),
 		},
 	}
-	if clusterIssuer {
-		cmCert.Spec.IssuerRef.Kind = "ClusterIssuer"
-	}
 	return cmCert
 }
 
-func valueOrDefault(value, dflt string) string {
-	if value == "" {
-		return dflt
+// issuerRefFor returns a reference to the issuer resolved for the given
+// Skupper Certificate.
+func issuerRefFor(obj *v2alpha1.Certificate) v2.ObjectReference {
+	resolution := ResolveIssuer(obj)
+	ref := v2.ObjectReference{
+		Name: resolution.Name,
+	}
+	if resolution.ClusterIssuer {
+		ref.Kind = "ClusterIssuer"
 	}
-	return value
+	return ref
+}
+
+// TrustBundleName returns the name of the ConfigMap holding the trust bundle
+// of the given CA.
+func TrustBundleName(caName string) string {
+	return caName + "-trust-bundle"
 }
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/kube/informer"
+)
+
+// Access check modes. Strict refuses to start when any permission is
+// missing, degrade only when a permission of a required feature is missing,
+// disabling the optional features that cannot be used.
+const (
+	AccessCheckStrict  = "strict"
+	AccessCheckDegrade = "degrade"
+	AccessCheckOff     = "off"
+)
+
+// checkAccess verifies the given permissions, logging a table of the missing
+// ones, and returns the capabilities the controller can use.
+func checkAccess(ctx context.Context, cli *client.Client, mode string, access []client.Access, log *slog.Logger) (informer.Capabilities, error) {
+	if mode == AccessCheckOff {
+		return informer.AllCapabilities(), nil
+	}
+	missing, err := cli.MissingAccess(ctx, access)
+	if err != nil {
+		return informer.Capabilities{}, err
+	}
+	if len(missing) == 0 {
+		return informer.AllCapabilities(), nil
+	}
+	log.WarnContext(ctx, "Missing permissions\n"+client.AccessTable(missing), "missing", len(missing), "access-check", mode)
+	caps, err := informer.CapabilitiesFor(missing)
+	if err != nil {
+		return caps, err
+	}
+	if mode == AccessCheckStrict {
+		return caps, fmt.Errorf("%w: %d permissions missing in strict mode", informer.ErrMissingAccess, len(missing))
+	}
+	log.WarnContext(ctx, "Running with reduced capabilities", "issuer-cleanup", caps.IssuerCleanup, "reissue", caps.Reissue, "adoption", caps.Adoption, "ca-rotation", caps.CARotation, "trust-bundles", caps.TrustBundles, "request-status", caps.RequestStatus, "approver-policy", caps.ApproverPolicy, "shared-root", caps.SharedRoot)
+	return caps, nil
+}
+
+// startupAccess returns the permissions verified before starting. With a
+// namespace selector, the permissions of each namespace are verified when
+// it starts matching the selector. The permissions of the cluster features
+// are only verified when they are enabled.
+func startupAccess(opts *ControllerOptions) []client.Access {
+	access := informer.AccessFor(opts.ControllerNamespace, informer.RulesFor(informer.ConfigRules, opts.ClusterFeatures))
+	access = append(access, informer.AccessFor("", informer.RulesFor(informer.ClusterRules, opts.ClusterFeatures))...)
+	switch {
+	case opts.NamespaceSelector != "":
+		access = append(access, informer.AccessFor("", informer.NamespaceSelectorRules)...)
+	case len(opts.Namespaces) > 0:
+		for _, namespace := range opts.Namespaces {
+			access = append(access, informer.AccessFor(namespace, informer.RulesFor(informer.CertificateRules, opts.ClusterFeatures))...)
+		}
+	default:
+		access = append(access, informer.AccessFor("", informer.RulesFor(informer.CertificateRules, opts.ClusterFeatures))...)
+	}
+	return access
+}
+
+// withClusterFeatures disables the cluster features not enabled, whose
+// permissions have not been verified.
+func withClusterFeatures(caps informer.Capabilities, opts *ControllerOptions, log *slog.Logger) informer.Capabilities {
+	var disabled []string
+	for _, feature := range informer.ClusterFeatures() {
+		if !slices.Contains(opts.ClusterFeatures, feature) {
+			caps.Disable(feature)
+			disabled = append(disabled, feature)
+		}
+	}
+	if len(disabled) > 0 {
+		log.Info("Cluster features disabled, enable them through --cluster-features", "disabled", strings.Join(disabled, ","))
+	}
+	return caps
+}
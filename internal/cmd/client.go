@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"time"
+
+	"skupper-cert-manager/internal/kube/client"
+)
+
+// ClientOptions holds the settings used to connect to the API server.
+type ClientOptions struct {
+	ClientMode        string
+	Kubeconfig        string
+	Context           string
+	APIServer         string
+	TokenFile         string
+	CAFile            string
+	Impersonate       string
+	ImpersonateGroups stringList
+	KubeAPIQPS        float64
+	KubeAPIBurst      int
+	KubeAPITimeout    time.Duration
+	UserAgent         string
+}
+
+func (o *ClientOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.ClientMode, "client-mode", client.ModeAuto, "how to connect to the API server (auto, in-cluster, kubeconfig or token)")
+	fs.StringVar(&o.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	fs.StringVar(&o.Context, "context", "", "kubeconfig context to use")
+	fs.StringVar(&o.APIServer, "api-server", "", "API server URL (token mode)")
+	fs.StringVar(&o.TokenFile, "token-file", "", "file holding the bearer token (token mode)")
+	fs.StringVar(&o.CAFile, "ca-file", "", "file holding the API server CA certificate (token mode)")
+	fs.StringVar(&o.Impersonate, "as", "", "user to impersonate, use system:serviceaccount:<namespace>:<name> for service accounts")
+	fs.Var(&o.ImpersonateGroups, "as-group", "comma separated list of groups to impersonate")
+	fs.Float64Var(&o.KubeAPIQPS, "kube-api-qps", client.DefaultQPS, "queries per second sent to the API server, shared by all clients")
+	fs.IntVar(&o.KubeAPIBurst, "kube-api-burst", client.DefaultBurst, "burst of queries sent to the API server, shared by all clients")
+	fs.DurationVar(&o.KubeAPITimeout, "kube-api-timeout", 0, "timeout of each API server request, watches excepted (no timeout if zero)")
+	fs.StringVar(&o.UserAgent, "user-agent", client.DefaultUserAgent, "user agent sent to the API server")
+}
+
+func (o *ClientOptions) Validate() error {
+	var errs []error
+	if o.KubeAPIQPS <= 0 || o.KubeAPIBurst < 1 {
+		errs = append(errs, errors.New("kube-api-qps and kube-api-burst must be greater than zero"))
+	}
+	if o.KubeAPITimeout < 0 {
+		errs = append(errs, errors.New("kube-api-timeout must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (o *ClientOptions) ClientConfig() client.Config {
+	return client.Config{
+		Mode:              o.ClientMode,
+		Kubeconfig:        o.Kubeconfig,
+		Context:           o.Context,
+		APIServer:         o.APIServer,
+		TokenFile:         o.TokenFile,
+		CAFile:            o.CAFile,
+		Impersonate:       o.Impersonate,
+		ImpersonateGroups: o.ImpersonateGroups,
+		QPS:               float32(o.KubeAPIQPS),
+		Burst:             o.KubeAPIBurst,
+		Timeout:           o.KubeAPITimeout,
+		UserAgent:         o.UserAgent,
+	}
+}
+
+func (o *ClientOptions) attrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String("client-mode", o.ClientMode),
+		slog.String("kubeconfig", o.Kubeconfig),
+		slog.String("context", o.Context),
+		slog.String("api-server", o.APIServer),
+		slog.String("token-file", o.TokenFile),
+		slog.String("ca-file", o.CAFile),
+		slog.String("as", o.Impersonate),
+		slog.String("as-group", o.ImpersonateGroups.String()),
+		slog.Float64("kube-api-qps", o.KubeAPIQPS),
+		slog.Int("kube-api-burst", o.KubeAPIBurst),
+		slog.Duration("kube-api-timeout", o.KubeAPITimeout),
+		slog.String("user-agent", o.UserAgent),
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/kube/informer"
+	"skupper-cert-manager/internal/logger"
+)
+
+// RunController runs the controller until SIGINT or SIGTERM is received.
+func RunController(args []string) error {
+	opts := &ControllerOptions{}
+	fs := flag.NewFlagSet("skupper-cert-manager", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := logger.Configure(opts.LoggerConfig()); err != nil {
+		return err
+	}
+	log := logger.NewLogger("controller", opts.ControllerNamespace)
+	log.Info("Starting skupper-cert-manager", "settings", opts)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	cli, err := client.NewClient(opts.ClientConfig())
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		log.Warn("Dry run mode, changes are validated by the API server but not persisted")
+	}
+	accessCtx, cancel := context.WithTimeout(ctx, opts.ReconcileTimeout)
+	defer cancel()
+	caps, err := checkAccess(accessCtx, cli, opts.AccessCheck, startupAccess(opts), log)
+	if err != nil {
+		return err
+	}
+	informerOpts := opts.InformerOptions()
+	informerOpts.Capabilities = withClusterFeatures(caps, opts, log)
+	eventProcessor := client.NewEventProcessor("", opts.Workers, opts.ReconcileTimeout)
+	if opts.LogLevelAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/loglevel", logger.LevelHandler())
+		go serve(ctx, opts.LogLevelAddress, mux, log)
+	}
+	if opts.HTTPAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})
+		mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+			if !eventProcessor.HasSynced() {
+				http.Error(w, "informers not synced", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		})
+		go serve(ctx, opts.HTTPAddress, mux, log)
+	}
+	configInformer := informer.NewConfigInformer(cli, opts.ControllerNamespace, opts.ConfigMap, informerOpts, func() {
+		for _, ei := range eventProcessor.Informers() {
+			if _, ok := ei.(*informer.SkupperCertificateInformer); ok {
+				eventProcessor.Resync(ei)
+			}
+		}
+	})
+	loadCtx, cancel := context.WithTimeout(ctx, opts.ReconcileTimeout)
+	defer cancel()
+	if err = configInformer.Load(loadCtx); err != nil {
+		return err
+	}
+	newInformers := func(namespaceOpts informer.Options, namespace string) []client.EventInformer {
+		informers := []client.EventInformer{
+			informer.NewSkupperCertificateInformer(cli, namespace, namespaceOpts),
+			informer.NewCertMgrCertificateInformer(cli, namespace, namespaceOpts),
+		}
+		if namespaceOpts.Capabilities.RequestStatus {
+			informers = append(informers, informer.NewCertificateRequestInformer(cli, namespace, namespaceOpts))
+		}
+		return informers
+	}
+	informers := []client.EventInformer{configInformer}
+	switch {
+	case opts.NamespaceSelector != "":
+		selected := func(ctx context.Context, namespace string) ([]client.EventInformer, error) {
+			namespaceOpts := informerOpts
+			caps, err := checkAccess(ctx, cli, opts.AccessCheck, informer.AccessFor(namespace, informer.RulesFor(informer.CertificateRules, opts.ClusterFeatures)), log.With("target-namespace", namespace))
+			if err != nil {
+				return nil, err
+			}
+			namespaceOpts.Capabilities = caps.Intersect(informerOpts.Capabilities)
+			return newInformers(namespaceOpts, namespace), nil
+		}
+		informers = append(informers, informer.NewNamespaceInformer(ctx, cli, opts.NamespaceSelector, informerOpts, eventProcessor, selected))
+	case len(opts.Namespaces) > 0:
+		for _, namespace := range opts.Namespaces {
+			informers = append(informers, newInformers(informerOpts, namespace)...)
+		}
+	default:
+		informers = append(informers, newInformers(informerOpts, "")...)
+	}
+	var informerErrors []error
+	for _, i := range informers {
+		informerErrors = append(informerErrors, eventProcessor.AddInformer(i))
+	}
+	if err = errors.Join(informerErrors...); err != nil {
+		return err
+	}
+	eventProcessor.StartInformers(ctx.Done())
+	eventProcessor.Start(ctx.Done())
+	<-ctx.Done()
+	log.Info("Shutting down")
+	return nil
+}
+
+// serve runs an HTTP server on address until ctx is done.
+func serve(ctx context.Context, address string, handler http.Handler, log *slog.Logger) {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error("HTTP server failed", "address", address, "error", err)
+	}
+}
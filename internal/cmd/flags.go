@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	envPrefix = "SKUPPER_CERT_MANAGER_"
+)
+
+// EnvName returns the environment variable that can be used to set the given
+// flag.
+func EnvName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// parseFlags sets the flags from their environment variables first, so that
+// flags given on the command line take precedence.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Usage = fmt.Sprintf("%s [$%s]", f.Usage, EnvName(f.Name))
+		value, ok := os.LookupEnv(EnvName(f.Name))
+		if !ok {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", value, EnvName(f.Name), err))
+		}
+	})
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return fs.Parse(args)
+}
+
+// stringList is a flag holding a comma separated list of values.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s = append(*s, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skupper-cert-manager/internal/kube/informer"
+	"skupper-cert-manager/internal/manifests"
+)
+
+// ManifestsOptions holds the settings of the manifests command.
+type ManifestsOptions struct {
+	manifests.Options
+	Namespaces stringList
+	Features   stringList
+	OutputDir  string
+}
+
+func (o *ManifestsOptions) AddFlags(fs *flag.FlagSet) {
+	o.Features = manifests.DefaultFeatures()
+	fs.StringVar(&o.Name, "name", manifests.DefaultName, "name of the rendered resources")
+	fs.StringVar(&o.Namespace, "namespace", manifests.DefaultNamespace, "namespace the controller is deployed to")
+	fs.StringVar(&o.Image, "image", manifests.DefaultImage, "controller image")
+	fs.IntVar(&o.HTTPPort, "http-port", manifests.DefaultHTTPPort, "port serving the health endpoints")
+	fs.Var(&o.Namespaces, "namespaces", "comma separated list of namespaces to watch (all namespaces if empty)")
+	fs.StringVar(&o.NamespaceSelector, "namespace-selector", "", "label selector of the namespaces to watch")
+	fs.Var(&o.Features, "features", "comma separated list of optional features granted permissions, the cluster features ("+strings.Join(informer.ClusterFeatures(), ", ")+") are only granted when listed")
+	fs.StringVar(&o.OutputDir, "output-dir", "", "directory the manifests and a kustomization.yaml are written to (standard output if empty)")
+}
+
+// RunManifests renders the manifests needed to deploy the controller, with
+// the RBAC rules required by the given scope and features.
+func RunManifests(args []string) error {
+	opts := &ManifestsOptions{}
+	fs := flag.NewFlagSet("skupper-cert-manager manifests", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	opts.Options.Namespaces = opts.Namespaces
+	opts.Options.Features = opts.Features
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	rendered := manifests.Render(opts.Options)
+	if opts.OutputDir == "" {
+		data, err := manifests.Marshal(rendered)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return writeManifests(opts.OutputDir, rendered)
+}
+
+// writeManifests writes the manifests to dir, grouped by file, along with a
+// kustomization.yaml.
+func writeManifests(dir string, rendered []manifests.Manifest) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var files []string
+	byFile := map[string][]manifests.Manifest{}
+	for _, m := range rendered {
+		if _, ok := byFile[m.File]; !ok {
+			files = append(files, m.File)
+		}
+		byFile[m.File] = append(byFile[m.File], m)
+	}
+	for _, file := range files {
+		data, err := manifests.Marshal(byFile[file])
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+			return fmt.Errorf("unable to write %s: %w", file, err)
+		}
+	}
+	data, err := manifests.Kustomization(rendered)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "kustomization.yaml"), data, 0644)
+}
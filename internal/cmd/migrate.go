@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/logger"
+	"skupper-cert-manager/internal/migrate"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MigrateOptions holds the settings of the migrate command.
+type MigrateOptions struct {
+	ClientOptions
+	Namespace         string
+	NamespaceSelector string
+	Rollback          bool
+	DryRun            bool
+	ReadyTimeout      time.Duration
+}
+
+func (o *MigrateOptions) AddFlags(fs *flag.FlagSet) {
+	o.ClientOptions.AddFlags(fs)
+	fs.StringVar(&o.Namespace, "namespace", "", "namespace whose certificates are delegated to cert-manager")
+	fs.StringVar(&o.NamespaceSelector, "namespace-selector", "", "label selector of the namespaces whose certificates are delegated to cert-manager")
+	fs.BoolVar(&o.Rollback, "rollback", false, "stop delegating the certificates and restore the Secrets backed up by a previous migration")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "send all changes as server side dry runs, without waiting for certificates")
+	fs.DurationVar(&o.ReadyTimeout, "ready-timeout", migrate.DefaultReadyTimeout, "maximum time waited for each certificate to become ready")
+}
+
+func (o *MigrateOptions) Validate() error {
+	errs := []error{o.ClientOptions.Validate()}
+	if (o.Namespace == "") == (o.NamespaceSelector == "") {
+		errs = append(errs, errors.New("either namespace or namespace-selector is required"))
+	}
+	if o.ReadyTimeout <= 0 {
+		errs = append(errs, errors.New("ready-timeout must be greater than zero"))
+	}
+	return errors.Join(errs...)
+}
+
+// RunMigrate delegates all certificates of the selected namespaces to
+// cert-manager, or rolls a previous migration back.
+func RunMigrate(args []string) error {
+	opts := &MigrateOptions{}
+	fs := flag.NewFlagSet("skupper-cert-manager migrate", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := logger.Configure(logger.Config{Level: "info", Format: logger.FormatText}); err != nil {
+		return err
+	}
+	config := opts.ClientConfig()
+	config.DryRun = opts.DryRun
+	cli, err := client.NewClient(config)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	namespaces := []string{opts.Namespace}
+	if opts.NamespaceSelector != "" {
+		list, err := cli.Kube.CoreV1().Namespaces().List(ctx, v1.ListOptions{LabelSelector: opts.NamespaceSelector})
+		if err != nil {
+			return err
+		}
+		namespaces = nil
+		for _, ns := range list.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	for _, namespace := range namespaces {
+		migrator := migrate.NewMigrator(cli, opts.ReadyTimeout, logger.NewLogger("migrate", namespace))
+		if opts.Rollback {
+			err = migrator.Rollback(ctx, namespace)
+		} else {
+			err = migrator.Migrate(ctx, namespace)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/kube/informer"
+	"skupper-cert-manager/internal/logger"
+)
+
+const (
+	defaultConfigMapName = "skupper-cert-manager"
+	serviceAccountNsFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// ControllerOptions holds the settings of the controller.
+type ControllerOptions struct {
+	ClientOptions
+	AccessCheck         string
+	ClusterFeatures     stringList
+	DryRun              bool
+	Namespaces          stringList
+	NamespaceSelector   string
+	ControllerNamespace string
+	ConfigMap           string
+	LogLevel            string
+	LogFormat           string
+	LogSource           bool
+	HTTPAddress         string
+	LogLevelAddress     string
+	ResyncPeriod        time.Duration
+	Workers             int
+	ReconcileTimeout    time.Duration
+	ReissueQPS          float64
+	ReissueBurst        int
+}
+
+func (o *ControllerOptions) AddFlags(fs *flag.FlagSet) {
+	o.ClientOptions.AddFlags(fs)
+	fs.StringVar(&o.AccessCheck, "access-check", AccessCheckDegrade, "how missing permissions are handled at startup (strict, degrade or off), degrade disables the optional features that cannot be used")
+	fs.Var(&o.ClusterFeatures, "cluster-features", "comma separated list of the optional features needing cluster wide permissions to enable ("+strings.Join(informer.ClusterFeatures(), ", ")+"), their permissions are only verified when enabled")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "send all changes as server side dry runs, logging the objects that would be written")
+	fs.Var(&o.Namespaces, "namespaces", "comma separated list of namespaces to watch (all namespaces if empty)")
+	fs.StringVar(&o.NamespaceSelector, "namespace-selector", "", "label selector of the namespaces to watch")
+	fs.StringVar(&o.ControllerNamespace, "controller-namespace", controllerNamespace(), "namespace the controller runs in")
+	fs.StringVar(&o.ConfigMap, "config-map", defaultConfigMapName, "name of the ConfigMap holding the configuration")
+	fs.StringVar(&o.LogLevel, "log-level", "info", "log level (debug, info, warn or error), optionally followed by per component levels, e.g.: info,informer.skupper=debug")
+	fs.StringVar(&o.LogFormat, "log-format", logger.FormatJSON, "log format (json or text)")
+	fs.BoolVar(&o.LogSource, "log-source", false, "include source locations in log records")
+	fs.StringVar(&o.HTTPAddress, "http-address", ":8080", "address serving the /healthz and /readyz endpoints (disabled if empty)")
+	fs.StringVar(&o.LogLevelAddress, "log-level-address", "localhost:8081", "address serving the unauthenticated /loglevel endpoint, which changes log levels at runtime (disabled if empty)")
+	fs.DurationVar(&o.ResyncPeriod, "resync-period", informer.DefaultResyncPeriod, "informers resync period")
+	fs.IntVar(&o.Workers, "workers", 1, "number of events processed in parallel")
+	fs.DurationVar(&o.ReconcileTimeout, "reconcile-timeout", client.DefaultReconcileTimeout, "maximum time spent processing a single event, including API calls")
+	fs.Float64Var(&o.ReissueQPS, "reissue-qps", informer.DefaultReissueQPS, "certificates reissued per second after an issuer change")
+	fs.IntVar(&o.ReissueBurst, "reissue-burst", informer.DefaultReissueBurst, "certificates reissued at once after an issuer change")
+}
+
+func (o *ControllerOptions) Validate() error {
+	errs := []error{o.ClientOptions.Validate()}
+	if len(o.Namespaces) > 0 && o.NamespaceSelector != "" {
+		errs = append(errs, errors.New("namespaces and namespace-selector are mutually exclusive"))
+	}
+	switch o.AccessCheck {
+	case AccessCheckStrict, AccessCheckDegrade, AccessCheckOff:
+	default:
+		errs = append(errs, errors.New("access-check must be strict, degrade or off"))
+	}
+	for _, feature := range o.ClusterFeatures {
+		if !slices.Contains(informer.ClusterFeatures(), feature) {
+			errs = append(errs, fmt.Errorf("unknown cluster feature %q, valid features are: %s", feature, strings.Join(informer.ClusterFeatures(), ", ")))
+		}
+	}
+	if o.ControllerNamespace == "" {
+		errs = append(errs, errors.New("controller-namespace is required"))
+	}
+	if o.Workers < 1 {
+		errs = append(errs, errors.New("workers must be greater than zero"))
+	}
+	if o.ReconcileTimeout <= 0 {
+		errs = append(errs, errors.New("reconcile-timeout must be greater than zero"))
+	}
+	if o.ResyncPeriod < 0 {
+		errs = append(errs, errors.New("resync-period must not be negative"))
+	}
+	if o.ReissueQPS <= 0 || o.ReissueBurst < 1 {
+		errs = append(errs, errors.New("reissue-qps and reissue-burst must be greater than zero"))
+	}
+	if err := logger.ValidateLevels(o.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if o.LogFormat != logger.FormatJSON && o.LogFormat != logger.FormatText {
+		errs = append(errs, errors.New("log-format must be json or text"))
+	}
+	return errors.Join(errs...)
+}
+
+func (o *ControllerOptions) ClientConfig() client.Config {
+	config := o.ClientOptions.ClientConfig()
+	config.DryRun = o.DryRun
+	return config
+}
+
+func (o *ControllerOptions) LoggerConfig() logger.Config {
+	return logger.Config{
+		Level:     o.LogLevel,
+		Format:    o.LogFormat,
+		AddSource: o.LogSource,
+	}
+}
+
+func (o *ControllerOptions) InformerOptions() informer.Options {
+	return informer.Options{
+		ResyncPeriod: o.ResyncPeriod,
+		Reissues:     informer.NewReissueLimiter(o.ReissueQPS, o.ReissueBurst),
+	}
+}
+
+func (o *ControllerOptions) LogValue() slog.Value {
+	return slog.GroupValue(append(o.ClientOptions.attrs(),
+		slog.String("access-check", o.AccessCheck),
+		slog.String("cluster-features", o.ClusterFeatures.String()),
+		slog.Bool("dry-run", o.DryRun),
+		slog.String("namespaces", o.Namespaces.String()),
+		slog.String("namespace-selector", o.NamespaceSelector),
+		slog.String("controller-namespace", o.ControllerNamespace),
+		slog.String("config-map", o.ConfigMap),
+		slog.String("log-level", o.LogLevel),
+		slog.String("log-format", o.LogFormat),
+		slog.Bool("log-source", o.LogSource),
+		slog.String("http-address", o.HTTPAddress),
+		slog.String("log-level-address", o.LogLevelAddress),
+		slog.Duration("resync-period", o.ResyncPeriod),
+		slog.Int("workers", o.Workers),
+		slog.Duration("reconcile-timeout", o.ReconcileTimeout),
+		slog.Float64("reissue-qps", o.ReissueQPS),
+		slog.Int("reissue-burst", o.ReissueBurst),
+	)...)
+}
+
+// controllerNamespace returns the namespace the controller is running in,
+// which is where its configuration is read from.
+func controllerNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	if ns, err := os.ReadFile(serviceAccountNsFile); err == nil {
+		return strings.TrimSpace(string(ns))
+	}
+	return "skupper"
+}
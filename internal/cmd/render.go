@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"skupper-cert-manager/internal/certmgr"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// RenderOptions holds the settings of the render command.
+type RenderOptions struct {
+	Filename  string
+	Config    string
+	Namespace string
+}
+
+func (o *RenderOptions) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Filename, "f", "-", "file holding the Skupper Certificates, - reads from standard input")
+	fs.StringVar(&o.Config, "config", "", "file holding the controller configuration (the config.yaml key of the ConfigMap)")
+	fs.StringVar(&o.Namespace, "namespace", "default", "namespace of the Skupper Certificates that have none")
+}
+
+// RunRender prints the cert-manager resources the controller would generate
+// for the Skupper Certificates read from a file, without using a cluster.
+func RunRender(args []string) error {
+	opts := &RenderOptions{}
+	fs := flag.NewFlagSet("skupper-cert-manager render", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if opts.Config != "" {
+		data, err := os.ReadFile(opts.Config)
+		if err != nil {
+			return err
+		}
+		cfg, err := certmgr.ParseConfig(data)
+		if err != nil {
+			return fmt.Errorf("invalid configuration %s: %w", opts.Config, err)
+		}
+		certmgr.SetConfig(cfg)
+	}
+	in := io.Reader(os.Stdin)
+	if opts.Filename != "-" {
+		f, err := os.Open(opts.Filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	certs, err := readCertificates(in, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	return render(os.Stdout, certs)
+}
+
+func readCertificates(in io.Reader, namespace string) ([]*v2alpha1.Certificate, error) {
+	var certs []*v2alpha1.Certificate
+	decoder := utilyaml.NewYAMLOrJSONDecoder(in, 4096)
+	for {
+		cert := &v2alpha1.Certificate{}
+		if err := decoder.Decode(cert); err != nil {
+			if errors.Is(err, io.EOF) {
+				return certs, nil
+			}
+			return nil, err
+		}
+		if cert.Kind != "Certificate" || cert.APIVersion != v2alpha1.SchemeGroupVersion.String() {
+			if cert.Kind != "" {
+				fmt.Fprintf(os.Stderr, "Skipping %s %s/%s\n", cert.Kind, cert.Namespace, cert.Name)
+			}
+			continue
+		}
+		if cert.Namespace == "" {
+			cert.Namespace = namespace
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// render writes the resources generated for each certificate, preceded by
+// comments telling which configuration entry the issuer was resolved from.
+func render(out io.Writer, certs []*v2alpha1.Certificate) error {
+	rootIssuers := map[string]bool{}
+	chains := map[string]bool{}
+	for _, cert := range certs {
+		var objects []runtime.Object
+		if rootIssuer, _ := certmgr.GetRootIssuer(cert.Namespace); rootIssuer == "" && !rootIssuers[cert.Namespace] {
+			rootIssuers[cert.Namespace] = true
+			objects = append(objects, certmgr.NewRootIssuer(cert.Namespace))
+		}
+		if chain := certmgr.GetChain(cert.Namespace); len(chain) > 0 && cert.Spec.Signing && !chains[cert.Namespace] {
+			chains[cert.Namespace] = true
+			for i, intermediate := range chain {
+				objects = append(objects, certmgr.NewIntermediateCertificate(cert.Namespace, chain, i), certmgr.NewIntermediateIssuer(cert.Namespace, intermediate))
+			}
+		}
+		if cert.Spec.Signing {
+			caCert := certmgr.NewCACertificate(cert, certmgr.NewNameConstraints(cert, signedHosts(certs, cert)))
+			objects = append(objects, caCert, certmgr.NewIssuer(cert))
+		} else {
+			objects = append(objects, certmgr.NewCertificate(cert))
+		}
+		resolution := certmgr.ResolveIssuer(cert)
+		kind := "Issuer"
+		if resolution.ClusterIssuer {
+			kind = "ClusterIssuer"
+		}
+		fmt.Fprintf(out, "---\n# Certificate %s/%s\n", cert.Namespace, cert.Name)
+		if !certmgr.IsManaged(cert) {
+			fmt.Fprintf(out, "# Not delegated to cert-manager, spec.settings.%s is not %q\n", certmgr.ControllerKey, certmgr.ControllerName)
+		}
+		fmt.Fprintf(out, "# Issued by %s %q, resolved from %s\n", kind, resolution.Name, resolution.Source)
+		for i, obj := range objects {
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				fmt.Fprintln(out, "---")
+			}
+			if _, err = out.Write(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// signedHosts returns the hosts of the delegated certificates signed by ca.
+func signedHosts(certs []*v2alpha1.Certificate, ca *v2alpha1.Certificate) []string {
+	var hosts []string
+	for _, cert := range certs {
+		if cert.Namespace == ca.Namespace && cert.Spec.Ca == ca.Name && certmgr.IsManaged(cert) {
+			hosts = append(hosts, cert.Spec.Hosts...)
+		}
+	}
+	return hosts
+}
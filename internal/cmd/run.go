@@ -0,0 +1,21 @@
+package cmd
+
+// Run runs the command named by the first argument, the controller being
+// run when no command is given.
+func Run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "controller":
+			return RunController(args[1:])
+		case "manifests":
+			return RunManifests(args[1:])
+		case "render":
+			return RunRender(args[1:])
+		case "status":
+			return RunStatus(args[1:])
+		case "migrate":
+			return RunMigrate(args[1:])
+		}
+	}
+	return RunController(args)
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/status"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// StatusOptions holds the settings of the status command.
+type StatusOptions struct {
+	ClientOptions
+	Namespace           string
+	ControllerNamespace string
+	ConfigMap           string
+	Output              string
+	Timeout             time.Duration
+}
+
+func (o *StatusOptions) AddFlags(fs *flag.FlagSet) {
+	o.ClientOptions.AddFlags(fs)
+	fs.StringVar(&o.Namespace, "namespace", "", "namespace to report on (all namespaces if empty)")
+	fs.StringVar(&o.ControllerNamespace, "controller-namespace", controllerNamespace(), "namespace the controller runs in")
+	fs.StringVar(&o.ConfigMap, "config-map", defaultConfigMapName, "name of the ConfigMap holding the configuration")
+	fs.StringVar(&o.Output, "output", OutputTable, "output format (table, json or yaml)")
+	fs.DurationVar(&o.Timeout, "timeout", time.Minute, "maximum time spent reading the cluster")
+}
+
+func (o *StatusOptions) Validate() error {
+	errs := []error{o.ClientOptions.Validate()}
+	switch o.Output {
+	case OutputTable, OutputJSON, OutputYAML:
+	default:
+		errs = append(errs, errors.New("output must be table, json or yaml"))
+	}
+	return errors.Join(errs...)
+}
+
+// RunStatus reports the status of the certificates delegated to cert-manager
+// and the inconsistencies found, failing when there are any.
+func RunStatus(args []string) error {
+	opts := &StatusOptions{}
+	fs := flag.NewFlagSet("skupper-cert-manager status", flag.ContinueOnError)
+	opts.AddFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	cli, err := client.NewClient(opts.ClientConfig())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	if err = loadConfig(ctx, cli, opts.ControllerNamespace, opts.ConfigMap); err != nil {
+		return err
+	}
+	report, err := status.Collect(ctx, cli, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	if err = writeReport(os.Stdout, report, opts.Output); err != nil {
+		return err
+	}
+	if !report.Consistent() {
+		return errors.New("inconsistencies found")
+	}
+	return nil
+}
+
+// loadConfig applies the configuration held by the given ConfigMap, keeping
+// the defaults when it does not exist.
+func loadConfig(ctx context.Context, cli *client.Client, namespace, name string) error {
+	configMap, err := cli.Kube.CoreV1().ConfigMaps(namespace).Get(ctx, name, v1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to load configuration from ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	cfg, err := certmgr.ParseConfig([]byte(configMap.Data[certmgr.ConfigKey]))
+	if err != nil {
+		return fmt.Errorf("invalid configuration in ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	certmgr.SetConfig(cfg)
+	return nil
+}
+
+func writeReport(out io.Writer, report *status.Report, output string) error {
+	switch output {
+	case OutputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case OutputYAML:
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAMESPACE\tNAME\tCA\tISSUER\tSOURCE\tREADY\tEXPIRY\tPROBLEMS")
+	for _, cert := range report.Certificates {
+		ca := cert.CA
+		if cert.Signing {
+			ca = "(signing)"
+		}
+		expiry := "-"
+		if cert.Expiry != nil {
+			expiry = cert.Expiry.Format(time.RFC3339)
+		}
+		problems := "-"
+		if len(cert.Problems) > 0 {
+			problems = strings.Join(cert.Problems, "; ")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s/%s\t%s\t%s\t%s\t%s\n", cert.Namespace, cert.Name, ca,
+			cert.IssuerKind, cert.Issuer, cert.IssuerSource, cert.Ready, expiry, problems)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(report.Issuers) > 0 {
+		_, _ = fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tROLE\tPATHLEN\tPROBLEM")
+		for _, issuer := range report.Issuers {
+			namespace, pathLen, problem := issuer.Namespace, issuer.PathLen, issuer.Problem
+			if namespace == "" {
+				namespace = "-"
+			}
+			if pathLen == "" {
+				pathLen = "-"
+			}
+			if problem == "" {
+				problem = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", namespace, issuer.Kind, issuer.Name, issuer.Role, pathLen, problem)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(report.Orphans) == 0 {
+		return nil
+	}
+	_, _ = fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAMESPACE\tKIND\tNAME\tPROBLEM")
+	for _, orphan := range report.Orphans {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", orphan.Namespace, orphan.Kind, orphan.Name, orphan.Problem)
+	}
+	return w.Flush()
+}
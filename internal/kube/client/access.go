@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Access is a permission the controller needs. An empty Namespace means
+// all namespaces, or a cluster scoped resource. Feature names what the
+// permission is needed for.
+type Access struct {
+	Namespace string
+	Group     string
+	Resource  string
+	Verb      string
+	Feature   string
+	Optional  bool
+}
+
+func (a Access) String() string {
+	resource := a.Resource
+	if a.Group != "" {
+		resource += "." + a.Group
+	}
+	namespace := a.Namespace
+	if namespace == "" {
+		namespace = "*"
+	}
+	return fmt.Sprintf("%s %s in %s", a.Verb, resource, namespace)
+}
+
+// CanI reports whether the current user has the given permission, using a
+// SelfSubjectAccessReview.
+func (c *Client) CanI(ctx context.Context, access Access) (bool, string, error) {
+	resource, subresource, _ := strings.Cut(access.Resource, "/")
+	review := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace:   access.Namespace,
+				Verb:        access.Verb,
+				Group:       access.Group,
+				Resource:    resource,
+				Subresource: subresource,
+			},
+		},
+	}
+	res, err := c.Kube.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, v1.CreateOptions{})
+	if err != nil {
+		return false, "", err
+	}
+	return res.Status.Allowed, res.Status.Reason, nil
+}
+
+// MissingAccess returns the permissions that are not granted.
+func (c *Client) MissingAccess(ctx context.Context, access []Access) ([]Access, error) {
+	var missing []Access
+	for _, a := range access {
+		allowed, _, err := c.CanI(ctx, a)
+		if err != nil {
+			return nil, fmt.Errorf("unable to verify permissions: %w", err)
+		}
+		if !allowed {
+			missing = append(missing, a)
+		}
+	}
+	return missing, nil
+}
+
+// AccessTable renders the given permissions as a table.
+func AccessTable(access []Access) string {
+	buf := &strings.Builder{}
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAMESPACE\tVERB\tRESOURCE\tFEATURE\tOPTIONAL")
+	for _, a := range access {
+		namespace := a.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+		resource := a.Resource
+		if a.Group != "" {
+			resource += "." + a.Group
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", namespace, a.Verb, resource, a.Feature, a.Optional)
+	}
+	_ = w.Flush()
+	return buf.String()
+}
@@ -1,47 +1,109 @@
 package client
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
 	"reflect"
+	"time"
+
+	"skupper-cert-manager/internal/logger"
 
 	cmclientset "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
 	skclientset "github.com/skupperproject/skupper/pkg/generated/client/clientset/versioned"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// ModeAuto uses the kubeconfig when found, falling back to the in-cluster
+	// configuration otherwise
+	ModeAuto = "auto"
+	// ModeInCluster uses the pod's service account
+	ModeInCluster = "in-cluster"
+	// ModeKubeconfig requires a kubeconfig file
+	ModeKubeconfig = "kubeconfig"
+	// ModeToken uses an API server URL and a bearer token file
+	ModeToken = "token"
+
+	DefaultQPS       = 20
+	DefaultBurst     = 40
+	DefaultUserAgent = "skupper-cert-manager"
 )
 
+// Config holds the settings used to connect to the API server.
+type Config struct {
+	Mode       string
+	Kubeconfig string
+	Context    string
+	APIServer  string
+	TokenFile  string
+	CAFile     string
+	// Impersonate is a user name, or system:serviceaccount:<namespace>:<name>
+	// to impersonate a service account
+	Impersonate       string
+	ImpersonateGroups []string
+	// QPS and Burst are shared by all clientsets
+	QPS   float32
+	Burst int
+	// Timeout bounds each request, watches excepted, so that it does not cut
+	// off the informers
+	Timeout   time.Duration
+	UserAgent string
+	// DryRun sends all writes as server side dry runs
+	DryRun bool
+}
+
 type Client struct {
 	CertManager *cmclientset.Clientset
 	Skupper     *skclientset.Clientset
 	Kube        *kubernetes.Clientset
+	Dynamic     dynamic.Interface
+	DryRun      bool
+	logger      *slog.Logger
 }
 
-func NewClient(kubeContext, kubeConfig string) (*Client, error) {
+func NewClient(config Config) (*Client, error) {
 	c := new(Client)
 
-	loader := clientcmd.NewDefaultClientConfigLoadingRules()
-	if kubeConfig != "" {
-		loader.ExplicitPath = kubeConfig
+	cfg, err := restConfig(config)
+	if err != nil {
+		return nil, err
 	}
-	overrides := &clientcmd.ConfigOverrides{
-		CurrentContext: kubeContext,
+	if err = configure(cfg, config); err != nil {
+		return nil, err
 	}
-	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides).ClientConfig()
+
+	// Clients: cert-manager, skupper, core and dynamic, sharing the same transport
+	httpClient, err := rest.HTTPClientFor(cfg)
 	if err != nil {
 		return nil, err
 	}
-
-	// Clients: cert-manager, skupper and core
-	cm, err := cmclientset.NewForConfig(cfg)
+	cm, err := cmclientset.NewForConfigAndClient(cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := skclientset.NewForConfigAndClient(cfg, httpClient)
 	if err != nil {
 		return nil, err
 	}
-	sk, err := skclientset.NewForConfig(cfg)
+	kubeCfg := rest.CopyConfig(cfg)
+	kubeCfg.ContentType = runtime.ContentTypeProtobuf
+	kubeCfg.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	k8s, err := kubernetes.NewForConfigAndClient(kubeCfg, httpClient)
 	if err != nil {
 		return nil, err
 	}
-	k8s, err := kubernetes.NewForConfig(cfg)
+	dyn, err := dynamic.NewForConfigAndClient(cfg, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -49,9 +111,130 @@ func NewClient(kubeContext, kubeConfig string) (*Client, error) {
 	c.CertManager = cm
 	c.Skupper = sk
 	c.Kube = k8s
+	c.Dynamic = dyn
+	c.DryRun = config.DryRun
+	c.logger = logger.NewLogger("client", "")
 	return c, nil
 }
 
+func restConfig(config Config) (*rest.Config, error) {
+	switch config.Mode {
+	case ModeAuto, "":
+		return kubeconfig(config)
+	case ModeInCluster:
+		cfg, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("in-cluster mode: %w", err)
+		}
+		return cfg, nil
+	case ModeKubeconfig:
+		cfg, err := kubeconfig(config)
+		if clientcmd.IsEmptyConfig(err) {
+			return nil, fmt.Errorf("kubeconfig mode: no kubeconfig found, use --kubeconfig or set KUBECONFIG")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig mode: %w", err)
+		}
+		return cfg, nil
+	case ModeToken:
+		if config.APIServer == "" || config.TokenFile == "" {
+			return nil, fmt.Errorf("token mode: both the API server and the token file are required")
+		}
+		if _, err := os.Stat(config.TokenFile); err != nil {
+			return nil, fmt.Errorf("token mode: unable to read token file: %w", err)
+		}
+		cfg := &rest.Config{
+			Host:            config.APIServer,
+			BearerTokenFile: config.TokenFile,
+			TLSClientConfig: rest.TLSClientConfig{
+				CAFile: config.CAFile,
+			},
+		}
+		return cfg, nil
+	default:
+		return nil, fmt.Errorf("invalid client mode %q, expected one of: %s, %s, %s or %s",
+			config.Mode, ModeAuto, ModeInCluster, ModeKubeconfig, ModeToken)
+	}
+}
+
+func kubeconfig(config Config) (*rest.Config, error) {
+	loader := clientcmd.NewDefaultClientConfigLoadingRules()
+	if config.Kubeconfig != "" {
+		loader.ExplicitPath = config.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: config.Context,
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides).ClientConfig()
+}
+
+// configure applies the rate limits, timeout and user agent to cfg. A single
+// rate limiter is set, so that the limits apply to all clientsets together.
+// The timeout is not set as cfg.Timeout, which would end watches as well.
+func configure(cfg *rest.Config, config Config) error {
+	if config.QPS < 0 || config.Burst < 0 || config.Timeout < 0 {
+		return fmt.Errorf("invalid client settings: qps=%v burst=%d timeout=%v", config.QPS, config.Burst, config.Timeout)
+	}
+	cfg.QPS = config.QPS
+	if cfg.QPS == 0 {
+		cfg.QPS = DefaultQPS
+	}
+	cfg.Burst = config.Burst
+	if cfg.Burst == 0 {
+		cfg.Burst = DefaultBurst
+	}
+	if config.Timeout > 0 {
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &timeoutRoundTripper{next: rt, timeout: config.Timeout}
+		})
+	}
+	cfg.UserAgent = config.UserAgent
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	cfg.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(cfg.QPS, cfg.Burst)
+	if config.Impersonate != "" {
+		cfg.Impersonate = rest.ImpersonationConfig{
+			UserName: config.Impersonate,
+			Groups:   config.ImpersonateGroups,
+		}
+	} else if len(config.ImpersonateGroups) > 0 {
+		return fmt.Errorf("impersonating groups requires a user to impersonate")
+	}
+	return nil
+}
+
+// timeoutRoundTripper bounds each request but watches with a context
+// deadline, released once the response body is closed.
+type timeoutRoundTripper struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("watch") == "true" {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
 func IsOwnedBy(ownedObj, ownerObj v1.Object, gvk schema.GroupVersionKind) bool {
 	current := v1.GetControllerOf(ownedObj)
 	expected := v1.NewControllerRef(ownerObj, gvk)
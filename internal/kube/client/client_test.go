@@ -0,0 +1,54 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTimeoutRoundTripper(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		wantDeadline bool
+	}{
+		{name: "get", url: "https://api/apis/cert-manager.io/v1/namespaces/ns/certificates/a", wantDeadline: true},
+		{name: "list", url: "https://api/apis/cert-manager.io/v1/certificates?limit=500", wantDeadline: true},
+		{name: "watch", url: "https://api/apis/cert-manager.io/v1/certificates?watch=true", wantDeadline: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			rt := &timeoutRoundTripper{
+				timeout: time.Minute,
+				next: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+					req = r
+					return &http.Response{Body: io.NopCloser(strings.NewReader(""))}, nil
+				}),
+			}
+			r, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			resp, err := rt.RoundTrip(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := req.Context().Deadline(); ok != tt.wantDeadline {
+				t.Fatalf("deadline set = %v, want %v", ok, tt.wantDeadline)
+			}
+			if req.Context().Err() != nil {
+				t.Fatal("request context done before the body is closed")
+			}
+			resp.Body.Close()
+			if tt.wantDeadline && req.Context().Err() == nil {
+				t.Fatal("request context not released when the body is closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+
+	cmscheme "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/scheme"
+	skscheme "github.com/skupperproject/skupper/pkg/generated/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// scheme resolves the kind of the objects logged in dry run mode, as objects
+// returned by the API server have no type information.
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = kubescheme.AddToScheme(scheme)
+	_ = cmscheme.AddToScheme(scheme)
+	_ = skscheme.AddToScheme(scheme)
+}
+
+func (c *Client) dryRun() []string {
+	if c.DryRun {
+		return []string{v1.DryRunAll}
+	}
+	return nil
+}
+
+// CreateOptions returns the options of create requests, which are only
+// validated by the API server in dry run mode.
+func (c *Client) CreateOptions() v1.CreateOptions {
+	return v1.CreateOptions{DryRun: c.dryRun()}
+}
+
+// UpdateOptions returns the options of update requests, which are only
+// validated by the API server in dry run mode.
+func (c *Client) UpdateOptions() v1.UpdateOptions {
+	return v1.UpdateOptions{DryRun: c.dryRun()}
+}
+
+// DeleteOptions returns the options of delete requests, which are only
+// validated by the API server in dry run mode.
+func (c *Client) DeleteOptions() v1.DeleteOptions {
+	return v1.DeleteOptions{DryRun: c.dryRun()}
+}
+
+// LogDryRun logs the object the given action would have written, when in dry
+// run mode.
+func (c *Client) LogDryRun(ctx context.Context, action string, obj runtime.Object) {
+	if !c.DryRun {
+		return
+	}
+	obj = obj.DeepCopyObject()
+	if kinds, _, err := scheme.ObjectKinds(obj); err == nil && len(kinds) > 0 {
+		obj.GetObjectKind().SetGroupVersionKind(kinds[0])
+	}
+	attrs := []any{"action", action, "kind", obj.GetObjectKind().GroupVersionKind().Kind}
+	if meta, ok := obj.(v1.Object); ok {
+		meta.SetManagedFields(nil)
+		attrs = append(attrs, "target-namespace", meta.GetNamespace(), "target-name", meta.GetName())
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Dry run, unable to render object", append(attrs, "error", err)...)
+		return
+	}
+	c.logger.InfoContext(ctx, "Dry run, change not written", append(attrs, "object", string(data))...)
+}
@@ -1,8 +1,14 @@
 package client
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,26 +19,64 @@ import (
 	"k8s.io/client-go/util/workqueue"
 )
 
-const maxRequeues = 5
+const (
+	maxRequeues             = 5
+	DefaultReconcileTimeout = 30 * time.Second
+)
 
 type EventInformer interface {
+	Name() string
 	Informer() cache.SharedIndexInformer
-	Handle(key string) error
+	Handle(ctx context.Context, key string) error
+}
+
+// RequeueAfterError is returned by handlers that want an event to be
+// processed again after Delay, without it being accounted as a failure.
+type RequeueAfterError struct {
+	Delay  time.Duration
+	Reason string
 }
+
+func (r *RequeueAfterError) Error() string {
+	return fmt.Sprintf("requeue after %v: %s", r.Delay, r.Reason)
+}
+
+func RequeueAfter(delay time.Duration, reason string) error {
+	return &RequeueAfterError{Delay: delay, Reason: reason}
+}
+
+func IsRequeueAfter(err error) bool {
+	var requeue *RequeueAfterError
+	return errors.As(err, &requeue)
+}
+
 type Event struct {
 	Key     string
 	Handler EventInformer
 }
 
-func NewEventProcessor(namespace string) *EventProcessor {
+// NewEventProcessor creates an EventProcessor handling events with the given
+// number of workers. Each event must be handled within reconcileTimeout.
+func NewEventProcessor(namespace string, workers int, reconcileTimeout time.Duration) *EventProcessor {
+	if reconcileTimeout <= 0 {
+		reconcileTimeout = DefaultReconcileTimeout
+	}
 	return &EventProcessor{
-		queue:  workqueue.NewTypedRateLimitingQueue[Event](workqueue.DefaultTypedControllerRateLimiter[Event]()),
-		logger: logger.NewLogger("event-processor", namespace),
+		ctx:           context.Background(),
+		workers:       max(workers, 1),
+		timeout:       reconcileTimeout,
+		queue:         workqueue.NewTypedRateLimitingQueue[Event](workqueue.DefaultTypedControllerRateLimiter[Event]()),
+		registrations: map[EventInformer]cache.ResourceEventHandlerRegistration{},
+		logger:        logger.NewLogger("event-processor", namespace),
 	}
 }
 
 type EventProcessor struct {
 	eventInformers []EventInformer
+	ctx            context.Context
+	workers        int
+	timeout        time.Duration
+	registrations  map[EventInformer]cache.ResourceEventHandlerRegistration
 	queue          workqueue.TypedRateLimitingInterface[Event]
 	started        bool
 	mutex          sync.Mutex
@@ -40,14 +84,50 @@ type EventProcessor struct {
 }
 
 func (e *EventProcessor) AddInformer(ei EventInformer) error {
-	_, err := ei.Informer().AddEventHandler(e.newEventHandler(ei))
+	registration, err := ei.Informer().AddEventHandler(e.newEventHandler(ei))
 	if err != nil {
 		return err
 	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	e.eventInformers = append(e.eventInformers, ei)
+	e.registrations[ei] = registration
 	return nil
 }
 
+// StartInformer adds an informer after the EventProcessor has been started
+// and runs it until stopCh is closed.
+func (e *EventProcessor) StartInformer(ei EventInformer, stopCh <-chan struct{}) error {
+	if err := e.AddInformer(ei); err != nil {
+		return err
+	}
+	go ei.Informer().Run(stopCh)
+	return nil
+}
+
+// RemoveInformer stops delivering events from the given informer. The
+// informer itself must be stopped by the caller.
+func (e *EventProcessor) RemoveInformer(ei EventInformer) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.eventInformers = slices.DeleteFunc(e.eventInformers, func(i EventInformer) bool {
+		return i == ei
+	})
+	registration, ok := e.registrations[ei]
+	if !ok {
+		return nil
+	}
+	delete(e.registrations, ei)
+	return ei.Informer().RemoveEventHandler(registration)
+}
+
+// Informers returns the informers currently delivering events.
+func (e *EventProcessor) Informers() []EventInformer {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return slices.Clone(e.eventInformers)
+}
+
 func (e *EventProcessor) newEventHandler(handler EventInformer) cache.ResourceEventHandlerFuncs {
 	return cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -89,6 +169,16 @@ func (e *EventProcessor) newEventHandler(handler EventInformer) cache.ResourceEv
 	}
 }
 
+// Resync queues all objects known to the given informer for processing.
+func (e *EventProcessor) Resync(ei EventInformer) {
+	for _, key := range ei.Informer().GetStore().ListKeys() {
+		e.queue.Add(Event{
+			Key:     key,
+			Handler: ei,
+		})
+	}
+}
+
 func (e *EventProcessor) StartInformers(stopCh <-chan struct{}) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -102,8 +192,33 @@ func (e *EventProcessor) StartInformers(stopCh <-chan struct{}) {
 	e.started = true
 }
 
+// HasSynced reports whether the processor has been started and all of its
+// informers have synced.
+func (e *EventProcessor) HasSynced() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !e.started {
+		return false
+	}
+	for _, ei := range e.eventInformers {
+		if !ei.Informer().HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Start runs the workers until stopCh is closed. Events being handled
+// at that point have their context cancelled.
 func (e *EventProcessor) Start(stopCh <-chan struct{}) {
-	go wait.Until(e.run, time.Second, stopCh)
+	e.ctx = wait.ContextForChannel(stopCh)
+	for i := 0; i < e.workers; i++ {
+		go wait.Until(e.run, time.Second, stopCh)
+	}
+	go func() {
+		<-stopCh
+		e.queue.ShutDown()
+	}()
 }
 
 func (e *EventProcessor) run() {
@@ -117,21 +232,48 @@ func (e *EventProcessor) process() bool {
 		return false
 	}
 	defer e.queue.Done(event)
-	err := event.Handler.Handle(event.Key)
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	defer cancel()
+	ctx = logger.WithAttrs(ctx,
+		slog.String("reconcile-id", newReconcileID()),
+		slog.String("handler", event.Handler.Name()))
+	start := time.Now()
+	err := event.Handler.Handle(ctx, event.Key)
+	duration := time.Since(start)
+	var requeue *RequeueAfterError
+	if errors.As(err, &requeue) {
+		e.queue.Forget(event)
+		e.logger.DebugContext(ctx, "Reconcile deferred", "key", event.Key, "duration", duration,
+			"outcome", "requeued", "delay", requeue.Delay, "reason", requeue.Reason)
+		e.queue.AddAfter(event, requeue.Delay)
+		return true
+	}
 	if err != nil {
 		requeues := e.queue.NumRequeues(event)
 		if requeues > maxRequeues {
 			e.queue.Forget(event)
-			e.logger.Debug("unable to re-queue after processing time", "key", event.Key)
+			e.logger.ErrorContext(ctx, "Reconcile failed, unable to re-queue after processing time", "key", event.Key,
+				"duration", duration, "outcome", "dropped", "error", err)
 			return true
 		}
+		e.logger.WarnContext(ctx, "Reconcile failed", "key", event.Key, "duration", duration,
+			"outcome", "retry", "requeues", requeues, "error", err)
 		e.queue.AddRateLimited(event)
 		return true
 	}
+	e.logger.DebugContext(ctx, "Reconcile finished", "key", event.Key, "duration", duration, "outcome", "success")
 	e.queue.Forget(event)
 	return true
 }
 
+// newReconcileID returns a random id used to correlate the records logged
+// while processing a single event.
+func newReconcileID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
 func KeyFromObj(obj interface{}) (string, error) {
 	key, err := cache.MetaNamespaceKeyFunc(obj)
 	if err != nil {
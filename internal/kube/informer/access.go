@@ -0,0 +1,224 @@
+package informer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Features requiring permissions. Optional features are disabled when their
+// permissions are missing, instead of preventing the controller from starting.
+const (
+	FeatureWatch             = "watch"
+	FeatureReconcile         = "reconcile"
+	FeatureConfig            = "config"
+	FeatureNamespaceSelector = "namespace-selector"
+	FeatureIssuerCleanup     = "issuer-cleanup"
+	FeatureReissue           = "reissue"
+	FeatureAdoption          = "adoption"
+	FeatureCARotation        = "ca-rotation"
+	FeatureTrustBundles      = "trust-bundles"
+	FeatureRequestStatus     = "request-status"
+	FeatureApproverPolicy    = "approver-policy"
+	FeatureSharedRoot        = "shared-root"
+)
+
+var (
+	skupperGroup = v2alpha1.SchemeGroupVersion.Group
+	cmGroup      = cm.SchemeGroupVersion.Group
+	trustGroup   = certmgr.BundleGVR.Group
+	policyGroup  = certmgr.PolicyGVR.Group
+	rbacGroup    = rbacv1.GroupName
+)
+
+// Rule describes the verbs a feature needs on a resource.
+type Rule struct {
+	Group    string
+	Resource string
+	Verbs    []string
+	Feature  string
+	Optional bool
+}
+
+// CertificateRules are the rules needed in every namespace certificates are
+// watched in.
+var CertificateRules = []Rule{
+	{Group: skupperGroup, Resource: "certificates", Verbs: []string{"list", "watch"}, Feature: FeatureWatch},
+	{Group: cmGroup, Resource: "certificates", Verbs: []string{"list", "watch"}, Feature: FeatureWatch},
+	{Group: skupperGroup, Resource: "certificates", Verbs: []string{"get"}, Feature: FeatureReconcile},
+	{Group: skupperGroup, Resource: "certificates/status", Verbs: []string{"update"}, Feature: FeatureReconcile},
+	{Group: cmGroup, Resource: "certificates", Verbs: []string{"get", "create", "update"}, Feature: FeatureReconcile},
+	{Group: cmGroup, Resource: "issuers", Verbs: []string{"get", "create", "update"}, Feature: FeatureReconcile},
+	{Group: cmGroup, Resource: "issuers", Verbs: []string{"delete"}, Feature: FeatureIssuerCleanup, Optional: true},
+	{Group: cmGroup, Resource: "certificates/status", Verbs: []string{"update"}, Feature: FeatureReissue, Optional: true},
+	{Resource: "secrets", Verbs: []string{"get"}, Feature: FeatureAdoption, Optional: true},
+	{Resource: "secrets", Verbs: []string{"get"}, Feature: FeatureCARotation, Optional: true},
+	{Resource: "configmaps", Verbs: []string{"get", "create", "update"}, Feature: FeatureCARotation, Optional: true},
+	{Resource: "secrets", Verbs: []string{"get"}, Feature: FeatureTrustBundles, Optional: true},
+	{Resource: "configmaps", Verbs: []string{"get"}, Feature: FeatureTrustBundles, Optional: true},
+	{Group: cmGroup, Resource: "certificaterequests", Verbs: []string{"list", "watch"}, Feature: FeatureRequestStatus, Optional: true},
+	{Group: rbacGroup, Resource: "roles", Verbs: []string{"get", "create", "update", "delete"}, Feature: FeatureApproverPolicy, Optional: true},
+	{Group: rbacGroup, Resource: "rolebindings", Verbs: []string{"get", "create", "update", "delete"}, Feature: FeatureApproverPolicy, Optional: true},
+}
+
+// ClusterRules are the rules needed on cluster scoped resources, whatever
+// the namespaces watched, by the features given through ClusterFeatures.
+var ClusterRules = []Rule{
+	{Group: trustGroup, Resource: "bundles", Verbs: []string{"get", "create", "update", "delete"}, Feature: FeatureTrustBundles, Optional: true},
+	// use is required to grant it to cert-manager through the generated Roles
+	{Group: policyGroup, Resource: "certificaterequestpolicies", Verbs: []string{"get", "create", "update", "delete", "use"}, Feature: FeatureApproverPolicy, Optional: true},
+	{Group: cmGroup, Resource: "clusterissuers", Verbs: []string{"get", "create", "update"}, Feature: FeatureSharedRoot, Optional: true},
+}
+
+// ClusterFeatures returns the features of the ClusterRules. As they need
+// cluster wide permissions, they are only granted and verified on request.
+func ClusterFeatures() []string {
+	var features []string
+	for _, rule := range ClusterRules {
+		if !slices.Contains(features, rule.Feature) {
+			features = append(features, rule.Feature)
+		}
+	}
+	return features
+}
+
+// RulesFor returns the given rules, leaving out those of the cluster
+// features not listed in clusterFeatures.
+func RulesFor(rules []Rule, clusterFeatures []string) []Rule {
+	cluster := ClusterFeatures()
+	return slices.DeleteFunc(slices.Clone(rules), func(rule Rule) bool {
+		return slices.Contains(cluster, rule.Feature) && !slices.Contains(clusterFeatures, rule.Feature)
+	})
+}
+
+// ConfigRules are the rules needed in the controller namespace.
+var ConfigRules = []Rule{
+	{Resource: "configmaps", Verbs: []string{"get", "list", "watch"}, Feature: FeatureConfig},
+}
+
+// SharedRootRules are the rules needed in the namespace the shared root is
+// issued in, the controller namespace unless configured otherwise. As it is
+// only known once the configuration is loaded, they are verified then.
+var SharedRootRules = []Rule{
+	{Group: cmGroup, Resource: "issuers", Verbs: []string{"get", "create"}, Feature: FeatureSharedRoot, Optional: true},
+	{Group: cmGroup, Resource: "certificates", Verbs: []string{"get", "create", "update"}, Feature: FeatureSharedRoot, Optional: true},
+	{Group: rbacGroup, Resource: "roles", Verbs: []string{"get", "create", "update"}, Feature: FeatureApproverPolicy, Optional: true},
+	{Group: rbacGroup, Resource: "rolebindings", Verbs: []string{"get", "create", "update"}, Feature: FeatureApproverPolicy, Optional: true},
+}
+
+// NamespaceSelectorRules are the cluster wide rules needed when namespaces
+// are selected through a label selector.
+var NamespaceSelectorRules = []Rule{
+	{Resource: "namespaces", Verbs: []string{"list", "watch"}, Feature: FeatureNamespaceSelector},
+}
+
+// AccessFor expands the given rules into the permissions to verify in a
+// namespace.
+func AccessFor(namespace string, rules []Rule) []client.Access {
+	var access []client.Access
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			access = append(access, client.Access{
+				Namespace: namespace,
+				Group:     rule.Group,
+				Resource:  rule.Resource,
+				Verb:      verb,
+				Feature:   rule.Feature,
+				Optional:  rule.Optional,
+			})
+		}
+	}
+	return access
+}
+
+// Capabilities tells which optional features can be used.
+type Capabilities struct {
+	IssuerCleanup  bool
+	Reissue        bool
+	Adoption       bool
+	CARotation     bool
+	TrustBundles   bool
+	RequestStatus  bool
+	ApproverPolicy bool
+	SharedRoot     bool
+}
+
+func AllCapabilities() Capabilities {
+	return Capabilities{
+		IssuerCleanup:  true,
+		Reissue:        true,
+		Adoption:       true,
+		CARotation:     true,
+		TrustBundles:   true,
+		RequestStatus:  true,
+		ApproverPolicy: true,
+		SharedRoot:     true,
+	}
+}
+
+// Disable disables the given optional feature.
+func (c *Capabilities) Disable(feature string) {
+	switch feature {
+	case FeatureIssuerCleanup:
+		c.IssuerCleanup = false
+	case FeatureReissue:
+		c.Reissue = false
+	case FeatureAdoption:
+		c.Adoption = false
+	case FeatureCARotation:
+		c.CARotation = false
+	case FeatureTrustBundles:
+		c.TrustBundles = false
+	case FeatureRequestStatus:
+		c.RequestStatus = false
+	case FeatureApproverPolicy:
+		c.ApproverPolicy = false
+	case FeatureSharedRoot:
+		c.SharedRoot = false
+	}
+}
+
+// Intersect returns the features enabled in both c and other.
+func (c Capabilities) Intersect(other Capabilities) Capabilities {
+	return Capabilities{
+		IssuerCleanup:  c.IssuerCleanup && other.IssuerCleanup,
+		Reissue:        c.Reissue && other.Reissue,
+		Adoption:       c.Adoption && other.Adoption,
+		CARotation:     c.CARotation && other.CARotation,
+		TrustBundles:   c.TrustBundles && other.TrustBundles,
+		RequestStatus:  c.RequestStatus && other.RequestStatus,
+		ApproverPolicy: c.ApproverPolicy && other.ApproverPolicy,
+		SharedRoot:     c.SharedRoot && other.SharedRoot,
+	}
+}
+
+// CapabilitiesFor disables the optional features whose permissions are
+// missing. An error is returned when permissions of required features are
+// missing.
+func CapabilitiesFor(missing []client.Access) (Capabilities, error) {
+	caps := AllCapabilities()
+	var required []string
+	for _, access := range missing {
+		if !access.Optional {
+			required = append(required, access.String())
+			continue
+		}
+		caps.Disable(access.Feature)
+	}
+	if len(required) > 0 {
+		return caps, fmt.Errorf("missing required permissions: %s", strings.Join(required, ", "))
+	}
+	return caps, nil
+}
+
+// ErrMissingAccess is returned when permissions are missing and the access
+// check does not allow the controller to run degraded.
+var ErrMissingAccess = errors.New("missing permissions")
@@ -0,0 +1,175 @@
+package informer
+
+import (
+	"slices"
+	"testing"
+
+	"skupper-cert-manager/internal/kube/client"
+)
+
+func TestAccessFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		expected []client.Access
+	}{
+		{
+			name: "one access per verb",
+			rule: Rule{Group: "g", Resource: "r", Verbs: []string{"get", "list"}, Feature: FeatureWatch},
+			expected: []client.Access{
+				{Namespace: "ns1", Group: "g", Resource: "r", Verb: "get", Feature: FeatureWatch},
+				{Namespace: "ns1", Group: "g", Resource: "r", Verb: "list", Feature: FeatureWatch},
+			},
+		},
+		{
+			name: "optional feature",
+			rule: Rule{Resource: "issuers", Verbs: []string{"delete"}, Feature: FeatureIssuerCleanup, Optional: true},
+			expected: []client.Access{
+				{Namespace: "ns1", Resource: "issuers", Verb: "delete", Feature: FeatureIssuerCleanup, Optional: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AccessFor("ns1", []Rule{tt.rule}); !slices.Equal(got, tt.expected) {
+				t.Errorf("access %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		missing  []client.Access
+		expected func(*Capabilities)
+		err      bool
+	}{
+		{name: "nothing missing", expected: func(*Capabilities) {}},
+		{
+			name: "optional features",
+			missing: []client.Access{
+				{Resource: "issuers", Verb: "delete", Feature: FeatureIssuerCleanup, Optional: true},
+				{Resource: "certificates/status", Verb: "update", Feature: FeatureReissue, Optional: true},
+			},
+			expected: func(caps *Capabilities) {
+				caps.IssuerCleanup = false
+				caps.Reissue = false
+			},
+		},
+		{
+			name: "required feature",
+			missing: []client.Access{
+				{Resource: "certificates", Verb: "watch", Feature: FeatureWatch},
+				{Resource: "issuers", Verb: "delete", Feature: FeatureIssuerCleanup, Optional: true},
+			},
+			expected: func(caps *Capabilities) {
+				caps.IssuerCleanup = false
+			},
+			err: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := CapabilitiesFor(tt.missing)
+			if tt.err != (err != nil) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			expected := AllCapabilities()
+			tt.expected(&expected)
+			if caps != expected {
+				t.Errorf("capabilities %+v, expected %+v", caps, expected)
+			}
+		})
+	}
+}
+
+func TestRulesHaveKnownFeatures(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{name: "certificate", rules: CertificateRules},
+		{name: "cluster", rules: ClusterRules},
+		{name: "config", rules: ConfigRules},
+		{name: "shared root", rules: SharedRootRules},
+		{name: "namespace selector", rules: NamespaceSelectorRules},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, rule := range tt.rules {
+				if !rule.Optional {
+					continue
+				}
+				caps, err := CapabilitiesFor(AccessFor("", []Rule{rule}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if caps == AllCapabilities() {
+					t.Errorf("optional feature %q of %s is not mapped to a capability", rule.Feature, rule.Resource)
+				}
+			}
+		})
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	tests := []struct {
+		name            string
+		rules           []Rule
+		clusterFeatures []string
+		included        []string
+		excluded        []string
+	}{
+		{name: "cluster features not enabled", rules: ClusterRules, excluded: []string{FeatureTrustBundles, FeatureApproverPolicy}},
+		{name: "one cluster feature", rules: ClusterRules, clusterFeatures: []string{FeatureTrustBundles}, included: []string{FeatureTrustBundles}, excluded: []string{FeatureApproverPolicy}},
+		{name: "namespace rules", rules: CertificateRules, included: []string{FeatureReconcile}, excluded: []string{FeatureApproverPolicy}},
+		{name: "all cluster features", rules: CertificateRules, clusterFeatures: ClusterFeatures(), included: []string{FeatureReconcile, FeatureApproverPolicy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var features []string
+			for _, rule := range RulesFor(tt.rules, tt.clusterFeatures) {
+				features = append(features, rule.Feature)
+			}
+			for _, feature := range tt.included {
+				if !slices.Contains(features, feature) {
+					t.Errorf("rules of %s left out", feature)
+				}
+			}
+			for _, feature := range tt.excluded {
+				if slices.Contains(features, feature) {
+					t.Errorf("rules of %s included", feature)
+				}
+			}
+		})
+	}
+}
+
+func TestCapabilitiesIntersect(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected func(*Capabilities)
+	}{
+		{name: "same feature", a: FeatureTrustBundles, b: FeatureTrustBundles, expected: func(caps *Capabilities) {
+			caps.TrustBundles = false
+		}},
+		{name: "different features", a: FeatureTrustBundles, b: FeatureReissue, expected: func(caps *Capabilities) {
+			caps.TrustBundles = false
+			caps.Reissue = false
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := AllCapabilities(), AllCapabilities()
+			a.Disable(tt.a)
+			b.Disable(tt.b)
+			expected := AllCapabilities()
+			tt.expected(&expected)
+			if got := a.Intersect(b); got != expected {
+				t.Errorf("got %+v, expected %+v", got, expected)
+			}
+		})
+	}
+}
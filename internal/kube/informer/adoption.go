@@ -0,0 +1,168 @@
+package informer
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ConditionTypeAdopted = "Adopted"
+
+	adoptionAdopted  v2alpha1.StatusType = "Adopted"
+	adoptionReplaced v2alpha1.StatusType = "Replaced"
+)
+
+// adoptedSecret returns the certificate held by the Secret Skupper issued
+// for obj, when adoption is enabled and the Secret has not been issued by
+// cert-manager yet.
+func (c *SkupperCertificateInformer) adoptedSecret(ctx context.Context, obj *v2alpha1.Certificate) (*x509.Certificate, time.Duration, error) {
+	enabled, renewBefore := certmgr.GetAdoption(obj.Namespace)
+	if !enabled {
+		return nil, 0, nil
+	}
+	if !c.capabilities.Adoption {
+		c.logger.WarnContext(ctx, "Adoption disabled by missing permissions", "target-namespace", obj.Namespace, "target-name", obj.Name, "feature", FeatureAdoption)
+		return nil, 0, nil
+	}
+	secret, err := c.cli.Kube.CoreV1().Secrets(obj.Namespace).Get(ctx, obj.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, ok := secret.Annotations[cm.CertificateNameKey]; ok {
+		return nil, 0, nil
+	}
+	cert, err := parseSecretCertificate(secret, obj.Spec.Signing)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Unable to adopt existing Secret, it will be replaced", "target-namespace", obj.Namespace, "target-name", obj.Name, "error", err)
+		return nil, 0, nil
+	}
+	return cert, renewBefore, nil
+}
+
+// adoptCACert leaves the Secret of an adopted CA in place, so that the CA
+// Issuer keeps signing with the existing key pair, until renewBefore it
+// expires. The cert-manager Certificate is created then, which renews the CA
+// through the CA rotation, when enabled. It reports false when the CA is not
+// adopted or its rotation is due.
+func (c *SkupperCertificateInformer) adoptCACert(ctx context.Context, key string, obj *v2alpha1.Certificate) (bool, error) {
+	cert, renewBefore, err := c.adoptedSecret(ctx, obj)
+	if err != nil || cert == nil {
+		return false, err
+	}
+	rotateAt := cert.NotAfter.Add(-renewBefore)
+	if !time.Now().Before(rotateAt) {
+		if enabled, _ := certmgr.GetCARotation(obj.Namespace); !enabled {
+			c.logger.WarnContext(ctx, "Adopted CA due for rotation without CA rotation enabled, certificates it signed will not be trusted once renewed", "key", key, "not-after", cert.NotAfter)
+		} else {
+			c.logger.InfoContext(ctx, "Adopted CA due for rotation", "key", key, "not-after", cert.NotAfter)
+		}
+		return false, nil
+	}
+	c.logger.InfoContext(ctx, "Adopted existing CA Secret, deferring rotation", "key", key, "subject", cert.Subject.String(), "not-after", cert.NotAfter, "rotate-at", rotateAt)
+	// resyncs check whether the rotation is due, see adoptionDue
+	c.adoptions.Set(key, rotateAt)
+	c.handled(key, obj)
+	message := fmt.Sprintf("Adopted CA Secret, rotation scheduled at %s", rotateAt.Format(time.RFC3339))
+	return true, c.setAdopted(ctx, obj, message)
+}
+
+// adoptCertificate defers the creation of the cert-manager Certificate, which
+// makes cert-manager reissue the certificate, until renewBefore the adopted
+// certificate expires. It reports false when the certificate is not adopted
+// or its rotation is due.
+func (c *SkupperCertificateInformer) adoptCertificate(ctx context.Context, key string, obj *v2alpha1.Certificate) (bool, error) {
+	cert, renewBefore, err := c.adoptedSecret(ctx, obj)
+	if err != nil || cert == nil {
+		return false, err
+	}
+	rotateAt := cert.NotAfter.Add(-renewBefore)
+	delay := time.Until(rotateAt)
+	if delay <= 0 {
+		c.logger.InfoContext(ctx, "Adopted certificate due for rotation", "key", key, "not-after", cert.NotAfter)
+		return false, nil
+	}
+	c.logger.InfoContext(ctx, "Adopted existing Secret, deferring reissue", "key", key, "not-after", cert.NotAfter, "rotate-at", rotateAt)
+	c.adoptions.Set(key, rotateAt)
+	c.handled(key, obj)
+	message := fmt.Sprintf("Adopted Secret, reissue scheduled at %s", rotateAt.Format(time.RFC3339))
+	if err = c.setAdopted(ctx, obj, message); err != nil {
+		return true, err
+	}
+	return true, client.RequeueAfter(delay, "adopted certificate rotation")
+}
+
+// adoptionDue reports whether the certificate has been adopted and its
+// rotation is due, so that it must be handled again even though its spec has
+// not changed.
+func (c *SkupperCertificateInformer) adoptionDue(key string) bool {
+	rotateAt, ok := c.adoptions.Get(key)
+	return ok && !time.Now().Before(rotateAt)
+}
+
+func (c *SkupperCertificateInformer) setAdopted(ctx context.Context, obj *v2alpha1.Certificate, message string) error {
+	return UpdateSkupperCertificateStatus(ctx, c.cli, obj, func(status *v2alpha1.CertificateStatus, generation int64) bool {
+		adopted := status.SetCondition(ConditionTypeAdopted, v2alpha1.ConditionState{
+			Status:  v1.ConditionTrue,
+			Reason:  adoptionAdopted,
+			Message: message,
+		}, generation)
+		ready := status.SetCondition(v2alpha1.CONDITION_TYPE_READY, v2alpha1.ReadyCondition(), generation)
+		return adopted || ready
+	})
+}
+
+// adoptionEnded records that the adopted Secret of obj, if any, is now managed
+// by the cert-manager Certificate that has just been created.
+func (c *SkupperCertificateInformer) adoptionEnded(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
+	c.adoptions.Delete(key)
+	if !IsAdopted(obj) {
+		return nil
+	}
+	return UpdateSkupperCertificateStatus(ctx, c.cli, obj, func(status *v2alpha1.CertificateStatus, generation int64) bool {
+		return status.SetCondition(ConditionTypeAdopted, v2alpha1.ConditionState{
+			Status:  v1.ConditionFalse,
+			Reason:  adoptionReplaced,
+			Message: "Adopted Secret handed over to cert-manager",
+		}, generation)
+	})
+}
+
+// IsAdopted reports whether the Secret of obj has been adopted and is not
+// managed by a cert-manager Certificate yet.
+func IsAdopted(obj *v2alpha1.Certificate) bool {
+	return meta.IsStatusConditionTrue(obj.Status.Conditions, ConditionTypeAdopted)
+}
+
+func parseSecretCertificate(secret *corev1.Secret, ca bool) (*x509.Certificate, error) {
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, err
+	}
+	if ca {
+		if !cert.IsCA {
+			return nil, fmt.Errorf("secret holds no CA certificate")
+		}
+		if len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+			return nil, fmt.Errorf("secret holds no private key")
+		}
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	return cert, nil
+}
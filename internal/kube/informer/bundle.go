@@ -0,0 +1,153 @@
+package informer
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"skupper-cert-manager/internal/certmgr"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ensureTrustBundle maintains the trust-manager Bundle distributing the
+// given CA, or all CAs of its namespace depending on the configured scope.
+func (c *SkupperCertificateInformer) ensureTrustBundle(ctx context.Context, obj *v2alpha1.Certificate) error {
+	config := certmgr.GetTrustBundles()
+	if config == nil {
+		return nil
+	}
+	if !c.capabilities.TrustBundles {
+		c.logger.DebugContext(ctx, "Trust bundles disabled by missing permissions", "key", obj.Key(), "feature", FeatureTrustBundles)
+		return nil
+	}
+	cas := []*v2alpha1.Certificate{obj}
+	if config.Scope == certmgr.TrustBundleScopeNamespace {
+		cas = c.signingCertificates(obj.Namespace)
+	}
+	return c.applyTrustBundle(ctx, config, certmgr.BundleName(config.Scope, obj.Namespace, obj.Name), obj.Namespace, cas)
+}
+
+// removeTrustBundle stops distributing a deleted CA.
+func (c *SkupperCertificateInformer) removeTrustBundle(ctx context.Context, obj *v2alpha1.Certificate) error {
+	config := certmgr.GetTrustBundles()
+	if config == nil || !c.capabilities.TrustBundles {
+		return nil
+	}
+	name := certmgr.BundleName(config.Scope, obj.Namespace, obj.Name)
+	if config.Scope == certmgr.TrustBundleScopeNamespace {
+		return c.applyTrustBundle(ctx, config, name, obj.Namespace, c.signingCertificates(obj.Namespace))
+	}
+	return c.deleteTrustBundle(ctx, name, obj.Namespace)
+}
+
+func (c *SkupperCertificateInformer) signingCertificates(namespace string) []*v2alpha1.Certificate {
+	var cas []*v2alpha1.Certificate
+	for _, item := range c.informer.GetStore().List() {
+		cert := item.(*v2alpha1.Certificate)
+		if cert.Namespace == namespace && cert.Spec.Signing && certmgr.IsManaged(cert) {
+			cas = append(cas, cert)
+		}
+	}
+	slices.SortFunc(cas, func(a, b *v2alpha1.Certificate) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cas
+}
+
+func (c *SkupperCertificateInformer) applyTrustBundle(ctx context.Context, config *certmgr.TrustBundles, name, namespace string, cas []*v2alpha1.Certificate) error {
+	var caPEM strings.Builder
+	for _, ca := range cas {
+		data, err := c.caCertificates(ctx, ca)
+		if err != nil {
+			return err
+		}
+		caPEM.WriteString(data)
+	}
+	if caPEM.Len() == 0 {
+		return c.deleteTrustBundle(ctx, name, namespace)
+	}
+	desired := certmgr.NewBundle(name, namespace, caPEM.String(), *config)
+	hash := certmgr.SpecHashOf(desired)
+	if applied, ok := c.bundles.Get(name); ok && applied == hash {
+		return nil
+	}
+	bundlesCli := c.cli.Dynamic.Resource(certmgr.BundleGVR)
+	current, err := bundlesCli.Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		c.logger.InfoContext(ctx, "Creating trust bundle", "name", name, "target-namespace", namespace)
+		if _, err = bundlesCli.Create(ctx, desired, c.cli.CreateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "create", desired)
+		c.bundles.Set(name, hash)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.GetLabels()[certmgr.NamespaceLabel] != namespace {
+		c.logger.WarnContext(ctx, "Trust bundle not generated for the namespace, leaving it unchanged", "name", name, "target-namespace", namespace)
+		return nil
+	}
+	if certmgr.SpecHashOf(current) != hash {
+		c.logger.InfoContext(ctx, "Updating trust bundle", "name", name, "target-namespace", namespace)
+		current.Object["spec"] = desired.Object["spec"]
+		current.SetAnnotations(desired.GetAnnotations())
+		if _, err = bundlesCli.Update(ctx, current, c.cli.UpdateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "update", current)
+	}
+	c.bundles.Set(name, hash)
+	return nil
+}
+
+// deleteTrustBundle deletes the Bundle with the given name, as long as it
+// has been generated for the given namespace.
+func (c *SkupperCertificateInformer) deleteTrustBundle(ctx context.Context, name, namespace string) error {
+	c.bundles.Delete(name)
+	bundlesCli := c.cli.Dynamic.Resource(certmgr.BundleGVR)
+	current, err := bundlesCli.Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.GetLabels()[certmgr.NamespaceLabel] != namespace {
+		return nil
+	}
+	c.logger.InfoContext(ctx, "Removing trust bundle", "name", name)
+	if err = bundlesCli.Delete(ctx, name, c.cli.DeleteOptions()); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	c.cli.LogDryRun(ctx, "delete", current)
+	return nil
+}
+
+// caCertificates returns the PEM encoded certificates to trust for the
+// given CA: the rotation trust bundle when there is one, so that previous
+// CAs remain trusted during their grace period, or the CA certificate.
+func (c *SkupperCertificateInformer) caCertificates(ctx context.Context, ca *v2alpha1.Certificate) (string, error) {
+	if enabled, _ := certmgr.GetCARotation(ca.Namespace); enabled {
+		bundle, err := c.cli.Kube.CoreV1().ConfigMaps(ca.Namespace).Get(ctx, certmgr.TrustBundleName(ca.Name), v1.GetOptions{})
+		if err == nil && bundle.Data[trustBundleKey] != "" {
+			return bundle.Data[trustBundleKey], nil
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+	}
+	secret, err := c.cli.Kube.CoreV1().Secrets(ca.Namespace).Get(ctx, ca.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[corev1.TLSCertKey]), nil
+}
@@ -0,0 +1,47 @@
+package informer
+
+import (
+	"sync"
+)
+
+// Cache keeps the last handled version of the objects by key. It is safe for
+// concurrent use, as events for different keys are handled in parallel.
+type Cache[T any] struct {
+	mutex sync.RWMutex
+	items map[string]T
+}
+
+func NewCache[T any]() *Cache[T] {
+	return &Cache[T]{
+		items: map[string]T{},
+	}
+}
+
+func (c *Cache[T]) Get(key string) (T, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	item, ok := c.items[key]
+	return item, ok
+}
+
+func (c *Cache[T]) Set(key string, item T) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.items[key] = item
+}
+
+func (c *Cache[T]) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.items, key)
+}
+
+func (c *Cache[T]) Keys() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
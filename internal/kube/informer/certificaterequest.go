@@ -0,0 +1,200 @@
+package informer
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/logger"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	v1 "github.com/cert-manager/cert-manager/pkg/client/informers/externalversions/certmanager/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	certRequestInformerName = "informer.certificate-request"
+
+	// ConditionTypeCertificateRequest reports the state of the latest
+	// CertificateRequest of a delegated Skupper Certificate, so that denials
+	// and issuer errors are visible without inspecting cert-manager resources
+	ConditionTypeCertificateRequest = "CertificateRequest"
+
+	requestPendingApproval v2alpha1.StatusType = "PendingApproval"
+	requestApproved        v2alpha1.StatusType = "Approved"
+	requestDenied          v2alpha1.StatusType = cm.CertificateRequestReasonDenied
+	requestInvalid         v2alpha1.StatusType = "InvalidRequest"
+	requestFailed          v2alpha1.StatusType = cm.CertificateRequestReasonFailed
+	requestIssued          v2alpha1.StatusType = cm.CertificateRequestReasonIssued
+)
+
+func NewCertificateRequestInformer(cli *client.Client, namespace string, opts Options) *CertificateRequestInformer {
+	res := &CertificateRequestInformer{
+		informer: v1.NewCertificateRequestInformer(cli.CertManager, namespace, opts.ResyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		requests: NewCache[*cm.CertificateRequest](),
+		cli:      cli,
+		logger:   logger.NewLogger(certRequestInformerName, namespace),
+	}
+	return res
+}
+
+// CertificateRequestInformer surfaces the approval and issuance state of the
+// CertificateRequests created for the cert-manager Certificates generated by
+// the controller in the status of their Skupper Certificate.
+type CertificateRequestInformer struct {
+	informer cache.SharedIndexInformer
+	requests *Cache[*cm.CertificateRequest]
+	logger   *slog.Logger
+	cli      *client.Client
+}
+
+func (c *CertificateRequestInformer) Informer() cache.SharedIndexInformer {
+	return c.informer
+}
+
+func (c *CertificateRequestInformer) Name() string {
+	return certRequestInformerName
+}
+
+func (c *CertificateRequestInformer) Handle(ctx context.Context, key string) error {
+	return Handle(ctx, key, c)
+}
+
+func (c *CertificateRequestInformer) Filter(obj *cm.CertificateRequest) bool {
+	return requestCertificate(obj) != ""
+}
+
+func (c *CertificateRequestInformer) Add(ctx context.Context, key string, obj *cm.CertificateRequest) error {
+	c.requests.Set(key, obj)
+	return c.updateStatus(ctx, obj.Namespace, requestCertificate(obj))
+}
+
+func (c *CertificateRequestInformer) Update(ctx context.Context, key string, old, new *cm.CertificateRequest) error {
+	return c.Add(ctx, key, new)
+}
+
+func (c *CertificateRequestInformer) Delete(ctx context.Context, key string) error {
+	obj, ok := c.requests.Get(key)
+	c.requests.Delete(key)
+	if !ok {
+		return nil
+	}
+	// previous revisions are pruned by cert-manager, the latest remaining
+	// one is reported
+	return c.updateStatus(ctx, obj.Namespace, requestCertificate(obj))
+}
+
+func (c *CertificateRequestInformer) Reconcile(ctx context.Context, key string, new *cm.CertificateRequest) error {
+	return nil
+}
+
+func (c *CertificateRequestInformer) Cache() *Cache[*cm.CertificateRequest] {
+	return c.requests
+}
+
+func (c *CertificateRequestInformer) Equal(oldObj, newObj *cm.CertificateRequest) bool {
+	return RequestCondition(oldObj) == RequestCondition(newObj)
+}
+
+// updateStatus reports the state of the latest CertificateRequest of the
+// named certificate in the status of the Skupper Certificate it belongs to.
+func (c *CertificateRequestInformer) updateStatus(ctx context.Context, namespace, name string) error {
+	latest := c.latestRequest(namespace, name)
+	if latest == nil {
+		return nil
+	}
+	certsCli := c.cli.Skupper.SkupperV2alpha1().Certificates(namespace)
+	skupperCert, err := certsCli.Get(ctx, name, k8sv1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to get skupper certificate", "key", namespace+"/"+name, "error", err)
+		return err
+	}
+	if !certmgr.IsManaged(skupperCert) {
+		return nil
+	}
+	condition := RequestCondition(latest)
+	if condition.Status == k8sv1.ConditionFalse {
+		c.logger.WarnContext(ctx, "Certificate request not issued", "key", skupperCert.Key(), "request", latest.Name, "reason", condition.Reason, "message", condition.Message)
+	}
+	return UpdateSkupperCertificateStatus(ctx, c.cli, skupperCert, func(status *v2alpha1.CertificateStatus, generation int64) bool {
+		return status.SetCondition(ConditionTypeCertificateRequest, condition, generation)
+	})
+}
+
+func (c *CertificateRequestInformer) latestRequest(namespace, name string) *cm.CertificateRequest {
+	var latest *cm.CertificateRequest
+	var latestRevision int
+	for _, item := range c.informer.GetStore().List() {
+		request := item.(*cm.CertificateRequest)
+		if request.Namespace != namespace || requestCertificate(request) != name {
+			continue
+		}
+		revision, _ := strconv.Atoi(request.Annotations[cm.CertificateRequestRevisionAnnotationKey])
+		if latest == nil || revision > latestRevision {
+			latest, latestRevision = request, revision
+		}
+	}
+	return latest
+}
+
+// requestCertificate returns the name of the cert-manager Certificate
+// controlling the given request, if any.
+func requestCertificate(obj *cm.CertificateRequest) string {
+	owner := k8sv1.GetControllerOf(obj)
+	if owner == nil || owner.APIVersion != cm.SchemeGroupVersion.String() || owner.Kind != cm.CertificateKind {
+		return ""
+	}
+	return owner.Name
+}
+
+// RequestCondition summarizes the conditions of a CertificateRequest. Denied
+// and invalid requests, as well as issuer failures, are reported as False.
+// The message of a Ready condition that is False is kept for pending
+// requests.
+func RequestCondition(obj *cm.CertificateRequest) v2alpha1.ConditionState {
+	conditions := map[cm.CertificateRequestConditionType]cm.CertificateRequestCondition{}
+	for _, condition := range obj.Status.Conditions {
+		conditions[condition.Type] = condition
+	}
+	failed := func(reason v2alpha1.StatusType, condition cm.CertificateRequestCondition) v2alpha1.ConditionState {
+		return v2alpha1.ConditionState{
+			Status:  k8sv1.ConditionFalse,
+			Reason:  reason,
+			Message: obj.Name + ": " + condition.Message,
+		}
+	}
+	if condition, ok := conditions[cm.CertificateRequestConditionDenied]; ok && condition.Status == metav1.ConditionTrue {
+		return failed(requestDenied, condition)
+	}
+	if condition, ok := conditions[cm.CertificateRequestConditionInvalidRequest]; ok && condition.Status == metav1.ConditionTrue {
+		return failed(requestInvalid, condition)
+	}
+	ready := conditions[cm.CertificateRequestConditionReady]
+	switch {
+	case ready.Status == metav1.ConditionTrue:
+		return v2alpha1.ConditionState{Status: k8sv1.ConditionTrue, Reason: requestIssued, Message: obj.Name + " issued"}
+	case ready.Reason == cm.CertificateRequestReasonFailed:
+		return failed(requestFailed, ready)
+	}
+	// issuers report why a request is pending, e.g. while they are not ready,
+	// through the Ready condition
+	waiting := func(message string) string {
+		if ready.Status == metav1.ConditionFalse && ready.Message != "" {
+			return message + ": " + ready.Message
+		}
+		return message
+	}
+	if condition, ok := conditions[cm.CertificateRequestConditionApproved]; ok && condition.Status == metav1.ConditionTrue {
+		return v2alpha1.ConditionState{Status: k8sv1.ConditionTrue, Reason: requestApproved, Message: waiting(obj.Name + " approved, waiting for the issuer")}
+	}
+	return v2alpha1.ConditionState{Status: k8sv1.ConditionUnknown, Reason: requestPendingApproval, Message: waiting(obj.Name + " waiting for approval")}
+}
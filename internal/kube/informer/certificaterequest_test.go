@@ -0,0 +1,103 @@
+package informer
+
+import (
+	"strings"
+	"testing"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRequestCondition(t *testing.T) {
+	condition := func(conditionType cm.CertificateRequestConditionType, status metav1.ConditionStatus, reason, message string) cm.CertificateRequestCondition {
+		return cm.CertificateRequestCondition{Type: conditionType, Status: status, Reason: reason, Message: message}
+	}
+	approved := condition(cm.CertificateRequestConditionApproved, metav1.ConditionTrue, "policy.cert-manager.io", "approved")
+	tests := []struct {
+		name       string
+		conditions []cm.CertificateRequestCondition
+		status     k8sv1.ConditionStatus
+		reason     v2alpha1.StatusType
+		message    string
+	}{
+		{
+			name:    "pending approval",
+			status:  k8sv1.ConditionUnknown,
+			reason:  requestPendingApproval,
+			message: "waiting for approval",
+		},
+		{
+			name: "denied",
+			conditions: []cm.CertificateRequestCondition{
+				condition(cm.CertificateRequestConditionDenied, metav1.ConditionTrue, "policy.cert-manager.io", "no policy matched"),
+			},
+			status:  k8sv1.ConditionFalse,
+			reason:  requestDenied,
+			message: "no policy matched",
+		},
+		{
+			name: "invalid",
+			conditions: []cm.CertificateRequestCondition{
+				condition(cm.CertificateRequestConditionInvalidRequest, metav1.ConditionTrue, "", "bad CSR"),
+			},
+			status:  k8sv1.ConditionFalse,
+			reason:  requestInvalid,
+			message: "bad CSR",
+		},
+		{
+			name: "approved",
+			conditions: []cm.CertificateRequestCondition{
+				approved,
+				condition(cm.CertificateRequestConditionReady, metav1.ConditionFalse, cm.CertificateRequestReasonPending, ""),
+			},
+			status:  k8sv1.ConditionTrue,
+			reason:  requestApproved,
+			message: "approved, waiting for the issuer",
+		},
+		{
+			name: "approved with issuer not ready",
+			conditions: []cm.CertificateRequestCondition{
+				approved,
+				condition(cm.CertificateRequestConditionReady, metav1.ConditionFalse, cm.CertificateRequestReasonPending, "issuer skupper-site-ca not ready"),
+			},
+			status:  k8sv1.ConditionTrue,
+			reason:  requestApproved,
+			message: "waiting for the issuer: issuer skupper-site-ca not ready",
+		},
+		{
+			name: "failed",
+			conditions: []cm.CertificateRequestCondition{
+				approved,
+				condition(cm.CertificateRequestConditionReady, metav1.ConditionFalse, cm.CertificateRequestReasonFailed, "signing failed"),
+			},
+			status:  k8sv1.ConditionFalse,
+			reason:  requestFailed,
+			message: "signing failed",
+		},
+		{
+			name: "issued",
+			conditions: []cm.CertificateRequestCondition{
+				approved,
+				condition(cm.CertificateRequestConditionReady, metav1.ConditionTrue, cm.CertificateRequestReasonIssued, "issued"),
+			},
+			status:  k8sv1.ConditionTrue,
+			reason:  requestIssued,
+			message: "issued",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := &cm.CertificateRequest{ObjectMeta: k8sv1.ObjectMeta{Name: "site-1"}}
+			request.Status.Conditions = test.conditions
+			got := RequestCondition(request)
+			if got.Status != test.status || got.Reason != test.reason {
+				t.Errorf("got %s/%s, want %s/%s", got.Status, got.Reason, test.status, test.reason)
+			}
+			if !strings.HasPrefix(got.Message, request.Name) || !strings.HasSuffix(got.Message, test.message) {
+				t.Errorf("message %q, want %q for %s", got.Message, test.message, request.Name)
+			}
+		})
+	}
+}
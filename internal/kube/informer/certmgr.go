@@ -14,19 +14,23 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
-func NewCertMgrCertificateInformer(cli *client.Client, namespace string) *CertMgrCertificateInformer {
+const (
+	certMgrInformerName = "informer.cert-manager"
+)
+
+func NewCertMgrCertificateInformer(cli *client.Client, namespace string, opts Options) *CertMgrCertificateInformer {
 	res := &CertMgrCertificateInformer{
-		informer:     v1.NewCertificateInformer(cli.CertManager, namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
-		certificates: map[string]*cm.Certificate{},
+		informer:     v1.NewCertificateInformer(cli.CertManager, namespace, opts.ResyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		certificates: NewCache[*cm.Certificate](),
 		cli:          cli,
-		logger:       logger.NewLogger("informer.cert-manager", namespace),
+		logger:       logger.NewLogger(certMgrInformerName, namespace),
 	}
 	return res
 }
 
 type CertMgrCertificateInformer struct {
 	informer     cache.SharedIndexInformer
-	certificates map[string]*cm.Certificate
+	certificates *Cache[*cm.Certificate]
 	logger       *slog.Logger
 	cli          *client.Client
 }
@@ -35,44 +39,48 @@ func (c *CertMgrCertificateInformer) Informer() cache.SharedIndexInformer {
 	return c.informer
 }
 
-func (c *CertMgrCertificateInformer) Handle(key string) error {
-	return Handle(key, c)
+func (c *CertMgrCertificateInformer) Name() string {
+	return certMgrInformerName
+}
+
+func (c *CertMgrCertificateInformer) Handle(ctx context.Context, key string) error {
+	return Handle(ctx, key, c)
 }
 
 func (c *CertMgrCertificateInformer) Filter(obj *cm.Certificate) bool {
 	return client.IsOwnedBySkupper(obj)
 }
 
-func (c *CertMgrCertificateInformer) Add(key string, obj *cm.Certificate) error {
+func (c *CertMgrCertificateInformer) Add(ctx context.Context, key string, obj *cm.Certificate) error {
 	ready, reason := GetCertManagerCertificateReadyReason(obj)
-	c.certificates[key] = obj
+	c.certificates.Set(key, obj)
 	certsCli := c.cli.Skupper.SkupperV2alpha1().Certificates(obj.Namespace)
-	skupperCert, err := certsCli.Get(context.Background(), obj.Name, k8sv1.GetOptions{})
+	skupperCert, err := certsCli.Get(ctx, obj.Name, k8sv1.GetOptions{})
 	if err != nil {
-		c.logger.Error("Failed to get skupper certificate", "key", key, "error", err)
+		c.logger.ErrorContext(ctx, "Failed to get skupper certificate", "key", key, "error", err)
 		return err
 	}
-	c.logger.Info("updating skupper certificate status", "key", key, "ready", ready, "reason", reason)
-	err = SkupperCertificateReadyOrPending(c.cli, skupperCert, ready, reason)
+	c.logger.InfoContext(ctx, "updating skupper certificate status", "key", key, "ready", ready, "reason", reason)
+	err = SkupperCertificateReadyOrPending(ctx, c.cli, skupperCert, ready, reason)
 	return err
 }
 
-func (c *CertMgrCertificateInformer) Update(key string, old, new *cm.Certificate) error {
-	return c.Add(key, new)
+func (c *CertMgrCertificateInformer) Update(ctx context.Context, key string, old, new *cm.Certificate) error {
+	return c.Add(ctx, key, new)
 }
 
-func (c *CertMgrCertificateInformer) Delete(key string) error {
-	delete(c.certificates, key)
+func (c *CertMgrCertificateInformer) Delete(ctx context.Context, key string) error {
+	c.certificates.Delete(key)
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		return err
 	}
 	certsCli := c.cli.Skupper.SkupperV2alpha1().Certificates(namespace)
-	cert, err := certsCli.Get(context.Background(), name, k8sv1.GetOptions{})
+	cert, err := certsCli.Get(ctx, name, k8sv1.GetOptions{})
 	if err == nil {
-		err = SkupperCertificateReadyOrPending(c.cli, cert, false, "Pending")
+		err = SkupperCertificateReadyOrPending(ctx, c.cli, cert, false, "Pending")
 		if err != nil {
-			c.logger.Error("Error updating certificate status to pending",
+			c.logger.ErrorContext(ctx, "Error updating certificate status to pending",
 				"key", key, "error", err.Error())
 			return err
 		}
@@ -80,11 +88,11 @@ func (c *CertMgrCertificateInformer) Delete(key string) error {
 	return nil
 }
 
-func (c *CertMgrCertificateInformer) Reconcile(key string, new *cm.Certificate) error {
+func (c *CertMgrCertificateInformer) Reconcile(ctx context.Context, key string, new *cm.Certificate) error {
 	return nil
 }
 
-func (c *CertMgrCertificateInformer) Cache() map[string]*cm.Certificate {
+func (c *CertMgrCertificateInformer) Cache() *Cache[*cm.Certificate] {
 	return c.certificates
 }
 
@@ -102,3 +110,14 @@ func GetCertManagerCertificateReadyReason(obj *cm.Certificate) (bool, string) {
 	}
 	return false, string(metav1.ConditionUnknown)
 }
+
+// GetIssuerReadyMessage returns whether an Issuer or ClusterIssuer is ready,
+// along with the message of its Ready condition.
+func GetIssuerReadyMessage(conditions []cm.IssuerCondition) (bool, string) {
+	for _, condition := range conditions {
+		if condition.Type == cm.IssuerConditionReady {
+			return condition.Status == metav1.ConditionTrue, condition.Message
+		}
+	}
+	return false, "no Ready condition"
+}
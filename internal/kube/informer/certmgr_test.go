@@ -0,0 +1,29 @@
+package informer
+
+import (
+	"testing"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+)
+
+func TestGetIssuerReadyMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []cm.IssuerCondition
+		ready      bool
+		message    string
+	}{
+		{"no conditions", nil, false, "no Ready condition"},
+		{"ready", []cm.IssuerCondition{{Type: cm.IssuerConditionReady, Status: metav1.ConditionTrue, Message: "Signing CA verified"}}, true, "Signing CA verified"},
+		{"not ready", []cm.IssuerCondition{{Type: cm.IssuerConditionReady, Status: metav1.ConditionFalse, Message: `secret "skupper-root" not found`}}, false, `secret "skupper-root" not found`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ready, message := GetIssuerReadyMessage(test.conditions)
+			if ready != test.ready || message != test.message {
+				t.Errorf("GetIssuerReadyMessage() = %v, %q, want %v, %q", ready, message, test.ready, test.message)
+			}
+		})
+	}
+}
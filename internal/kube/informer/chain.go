@@ -0,0 +1,93 @@
+package informer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ensureChain maintains the intermediate CAs configured for the namespace,
+// and their CA Issuers, which the CAs of the namespace are issued from. They
+// are read on every reconcile, so that deleted ones are recreated.
+// Intermediates removed from the configuration are left in place, as
+// certificates they signed may still be in use.
+func (c *SkupperCertificateInformer) ensureChain(ctx context.Context, namespace string) error {
+	chain := certmgr.GetChain(namespace)
+	if len(chain) == 0 {
+		return nil
+	}
+	c.warnChainDepth(ctx, namespace, chain)
+	certsCli := c.cli.CertManager.CertmanagerV1().Certificates(namespace)
+	issuersCli := c.cli.CertManager.CertmanagerV1().Issuers(namespace)
+	for i, intermediate := range chain {
+		desired := certmgr.NewIntermediateCertificate(namespace, chain, i)
+		current, err := certsCli.Get(ctx, desired.Name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			c.logger.InfoContext(ctx, "Creating intermediate CA", "target-namespace", namespace, "name", desired.Name, "issuer", desired.Spec.IssuerRef.Name)
+			if _, err = certsCli.Create(ctx, desired, c.cli.CreateOptions()); err != nil {
+				return err
+			}
+			c.cli.LogDryRun(ctx, "create", desired)
+		} else if err != nil {
+			return err
+		} else if client.IsOwnedBySkupper(current) {
+			return fmt.Errorf("intermediate %s conflicts with the certificate of a Skupper Certificate in %s", desired.Name, namespace)
+		} else if certmgr.SpecHashOf(current) != certmgr.SpecHashOf(desired) {
+			c.logger.InfoContext(ctx, "Updating intermediate CA", "target-namespace", namespace, "name", desired.Name, "issuer", desired.Spec.IssuerRef.Name)
+			current.Spec = desired.Spec
+			v1.SetMetaDataAnnotation(&current.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(desired))
+			if _, err = certsCli.Update(ctx, current, c.cli.UpdateOptions()); err != nil {
+				return err
+			}
+			c.cli.LogDryRun(ctx, "update", current)
+		} else if ready, reason := GetCertManagerCertificateReadyReason(current); !ready {
+			c.logger.DebugContext(ctx, "Intermediate CA not ready", "target-namespace", namespace, "name", desired.Name, "reason", reason)
+		}
+		issuer := certmgr.NewIntermediateIssuer(namespace, intermediate)
+		currentIssuer, err := issuersCli.Get(ctx, issuer.Name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			c.logger.InfoContext(ctx, "Creating intermediate Issuer", "target-namespace", namespace, "name", issuer.Name)
+			if _, err = issuersCli.Create(ctx, issuer, c.cli.CreateOptions()); err != nil {
+				return err
+			}
+			c.cli.LogDryRun(ctx, "create", issuer)
+		} else if err != nil {
+			return err
+		} else if client.IsOwnedBySkupper(currentIssuer) {
+			return fmt.Errorf("intermediate %s conflicts with the issuer of a Skupper Certificate in %s", issuer.Name, namespace)
+		} else if certmgr.SpecHashOf(currentIssuer) != certmgr.SpecHashOf(issuer) {
+			c.logger.InfoContext(ctx, "Updating intermediate Issuer", "target-namespace", namespace, "name", issuer.Name)
+			currentIssuer.Spec = issuer.Spec
+			v1.SetMetaDataAnnotation(&currentIssuer.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(issuer))
+			if _, err = issuersCli.Update(ctx, currentIssuer, c.cli.UpdateOptions()); err != nil {
+				return err
+			}
+			c.cli.LogDryRun(ctx, "update", currentIssuer)
+		}
+	}
+	return nil
+}
+
+// warnChainDepth warns, once per chain, that the depth of the chain is not
+// enforced: cert-manager cannot set the path length of the intermediates, so
+// the CAs of the sites could sign further CAs unless the root issuer sets
+// it. The status command reports the path length of the issued
+// intermediates.
+func (c *SkupperCertificateInformer) warnChainDepth(ctx context.Context, namespace string, chain []certmgr.Intermediate) {
+	var names []string
+	for _, intermediate := range chain {
+		names = append(names, intermediate.Name)
+	}
+	key := strings.Join(names, ",")
+	if warned, ok := c.chains.Get(namespace); ok && warned == key {
+		return
+	}
+	c.chains.Set(namespace, key)
+	c.logger.WarnContext(ctx, "Path length of the intermediate CAs not enforced by cert-manager, the depth of the chain is not limited", "target-namespace", namespace, "intermediates", key, "expected-depth", len(chain)+1)
+}
@@ -1,6 +1,7 @@
 package informer
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,29 +9,44 @@ import (
 )
 
 const (
-	resyncPeriod = 30 * time.Second
+	DefaultResyncPeriod = 30 * time.Second
 )
 
+// Options holds the settings shared by the informers.
+type Options struct {
+	ResyncPeriod time.Duration
+	Reissues     *ReissueLimiter
+	Capabilities Capabilities
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ResyncPeriod: DefaultResyncPeriod,
+		Reissues:     NewReissueLimiter(DefaultReissueQPS, DefaultReissueBurst),
+		Capabilities: AllCapabilities(),
+	}
+}
+
 type ActionHandler[T any] interface {
 	Filter(obj T) bool
-	Add(key string, obj T) error
-	Delete(key string) error
-	Update(key string, old, new T) error
-	Reconcile(key string, new T) error
-	Cache() map[string]T
+	Add(ctx context.Context, key string, obj T) error
+	Delete(ctx context.Context, key string) error
+	Update(ctx context.Context, key string, old, new T) error
+	Reconcile(ctx context.Context, key string, new T) error
+	Cache() *Cache[T]
 	Equal(oldObj, newObj T) bool
 	client.EventInformer
 }
 
-func Handle[T any](key string, handler ActionHandler[T]) error {
+func Handle[T any](ctx context.Context, key string, handler ActionHandler[T]) error {
 	obj, exists, err := handler.Informer().GetStore().GetByKey(key)
 	if err != nil {
 		return fmt.Errorf("error retrieving key from informer store: %v", err)
 	}
-	oldObj, ok := handler.Cache()[key]
+	oldObj, ok := handler.Cache().Get(key)
 	if !exists {
 		if ok {
-			return handler.Delete(key)
+			return handler.Delete(ctx, key)
 		}
 		// removed unhandled key
 		return nil
@@ -40,10 +56,10 @@ func Handle[T any](key string, handler ActionHandler[T]) error {
 		return nil
 	}
 	if !ok {
-		return handler.Add(key, newObj)
+		return handler.Add(ctx, key, newObj)
 	}
 	if !handler.Equal(oldObj, newObj) {
-		return handler.Update(key, oldObj, newObj)
+		return handler.Update(ctx, key, oldObj, newObj)
 	}
-	return handler.Reconcile(key, newObj)
+	return handler.Reconcile(ctx, key, newObj)
 }
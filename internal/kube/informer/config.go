@@ -0,0 +1,171 @@
+package informer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// LogLevelKey holds the log levels to use at runtime, overriding the ones
+	// given on the command line, for example: "info,informer.skupper=debug".
+	LogLevelKey = "log-level"
+
+	configInformerName = "informer.config"
+)
+
+// NewConfigInformer watches the ConfigMap holding the controller configuration.
+// The onChange callback is invoked every time a new configuration is applied.
+func NewConfigInformer(cli *client.Client, namespace, name string, opts Options, onChange func()) *ConfigInformer {
+	res := &ConfigInformer{
+		informer: coreinformers.NewFilteredConfigMapInformer(cli.Kube, namespace, opts.ResyncPeriod, cache.Indexers{}, func(options *k8sv1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+		configMaps:   NewCache[*corev1.ConfigMap](),
+		rootAccess:   NewCache[bool](),
+		cli:          cli,
+		namespace:    namespace,
+		name:         name,
+		onChange:     onChange,
+		capabilities: opts.Capabilities,
+		logger:       logger.NewLogger(configInformerName, namespace),
+	}
+	return res
+}
+
+type ConfigInformer struct {
+	informer     cache.SharedIndexInformer
+	configMaps   *Cache[*corev1.ConfigMap]
+	rootAccess   *Cache[bool]
+	rootProblem  string
+	cli          *client.Client
+	namespace    string
+	name         string
+	onChange     func()
+	capabilities Capabilities
+	logger       *slog.Logger
+}
+
+func (c *ConfigInformer) Informer() cache.SharedIndexInformer {
+	return c.informer
+}
+
+func (c *ConfigInformer) Name() string {
+	return configInformerName
+}
+
+func (c *ConfigInformer) Handle(ctx context.Context, key string) error {
+	return Handle(ctx, key, c)
+}
+
+// Load reads and applies the configuration before the informers are
+// started, so that certificates are not processed using the defaults.
+func (c *ConfigInformer) Load(ctx context.Context) error {
+	configMap, err := c.cli.Kube.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, k8sv1.GetOptions{})
+	if errors.IsNotFound(err) {
+		c.logger.InfoContext(ctx, "Configuration not found, using defaults", "name", c.name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to load configuration from ConfigMap %s: %w", c.name, err)
+	}
+	key, _ := cache.MetaNamespaceKeyFunc(configMap)
+	return c.Add(ctx, key, configMap)
+}
+
+func (c *ConfigInformer) Filter(obj *corev1.ConfigMap) bool {
+	return obj.Name == c.name
+}
+
+func (c *ConfigInformer) Add(ctx context.Context, key string, obj *corev1.ConfigMap) error {
+	previous, _ := c.configMaps.Get(key)
+	c.applyLogLevels(ctx, key, previous, obj)
+	cfg, err := certmgr.ParseConfig([]byte(obj.Data[certmgr.ConfigKey]))
+	if err != nil {
+		// keeping the previous configuration, as retrying won't help
+		c.logger.ErrorContext(ctx, "Invalid configuration", "key", key, "error", err)
+		return nil
+	}
+	c.configMaps.Set(key, obj)
+	certmgr.SetConfig(cfg)
+	c.logger.InfoContext(ctx, "Configuration loaded", "key", key)
+	c.changed()
+	return c.ensureSharedRoot(ctx)
+}
+
+func (c *ConfigInformer) Delete(ctx context.Context, key string) error {
+	previous, _ := c.configMaps.Get(key)
+	c.configMaps.Delete(key)
+	if _, ok := logLevels(previous); ok {
+		logger.ResetLevels()
+	}
+	certmgr.SetConfig(&certmgr.ConfigFile{})
+	c.logger.InfoContext(ctx, "Configuration removed, using defaults", "key", key)
+	c.changed()
+	return nil
+}
+
+func (c *ConfigInformer) Update(ctx context.Context, key string, old, new *corev1.ConfigMap) error {
+	return c.Add(ctx, key, new)
+}
+
+func (c *ConfigInformer) Reconcile(ctx context.Context, key string, new *corev1.ConfigMap) error {
+	return c.ensureSharedRoot(ctx)
+}
+
+func (c *ConfigInformer) Cache() *Cache[*corev1.ConfigMap] {
+	return c.configMaps
+}
+
+func (c *ConfigInformer) Equal(oldObj, newObj *corev1.ConfigMap) bool {
+	return oldObj.Data[certmgr.ConfigKey] == newObj.Data[certmgr.ConfigKey] &&
+		oldObj.Data[LogLevelKey] == newObj.Data[LogLevelKey]
+}
+
+// applyLogLevels only applies the levels of the ConfigMap when they have
+// changed, and resets them when they have been removed, so that the levels
+// set through the /loglevel endpoint are kept otherwise.
+func (c *ConfigInformer) applyLogLevels(ctx context.Context, key string, previous, obj *corev1.ConfigMap) {
+	spec, ok := logLevels(obj)
+	previousSpec, previousOk := logLevels(previous)
+	if !ok {
+		if previousOk {
+			logger.ResetLevels()
+			c.logger.InfoContext(ctx, "Log levels reset", "key", key, "levels", logger.Levels())
+		}
+		return
+	}
+	if previousOk && spec == previousSpec {
+		return
+	}
+	if err := logger.SetLevels(spec); err != nil {
+		c.logger.ErrorContext(ctx, "Invalid log level", "key", key, "error", err)
+		return
+	}
+	c.logger.InfoContext(ctx, "Log levels changed", "key", key, "levels", logger.Levels())
+}
+
+func logLevels(obj *corev1.ConfigMap) (string, bool) {
+	if obj == nil {
+		return "", false
+	}
+	spec, ok := obj.Data[LogLevelKey]
+	return spec, ok
+}
+
+func (c *ConfigInformer) changed() {
+	if c.onChange != nil {
+		c.onChange()
+	}
+}
@@ -0,0 +1,141 @@
+package informer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	namespaceInformerName = "informer.namespace"
+)
+
+// InformerSet returns the informers that must run for a given namespace.
+type InformerSet func(ctx context.Context, namespace string) ([]client.EventInformer, error)
+
+// NewNamespaceInformer watches the namespaces matching the given label
+// selector, starting the informers returned by newInformers as namespaces
+// start matching the selector and stopping them once they no longer match.
+// All informers are stopped when ctx is done.
+func NewNamespaceInformer(ctx context.Context, cli *client.Client, selector string, opts Options, processor *client.EventProcessor, newInformers InformerSet) *NamespaceInformer {
+	res := &NamespaceInformer{
+		informer: coreinformers.NewFilteredNamespaceInformer(cli.Kube, opts.ResyncPeriod, cache.Indexers{}, func(options *k8sv1.ListOptions) {
+			options.LabelSelector = selector
+		}),
+		namespaces:   NewCache[*corev1.Namespace](),
+		running:      map[string]runningInformers{},
+		processor:    processor,
+		newInformers: newInformers,
+		ctx:          ctx,
+		logger:       logger.NewLogger(namespaceInformerName, ""),
+	}
+	return res
+}
+
+type runningInformers struct {
+	informers []client.EventInformer
+	cancel    context.CancelFunc
+}
+
+type NamespaceInformer struct {
+	informer     cache.SharedIndexInformer
+	namespaces   *Cache[*corev1.Namespace]
+	running      map[string]runningInformers
+	mutex        sync.Mutex
+	processor    *client.EventProcessor
+	newInformers InformerSet
+	ctx          context.Context
+	logger       *slog.Logger
+}
+
+func (n *NamespaceInformer) Informer() cache.SharedIndexInformer {
+	return n.informer
+}
+
+func (n *NamespaceInformer) Name() string {
+	return namespaceInformerName
+}
+
+func (n *NamespaceInformer) Handle(ctx context.Context, key string) error {
+	return Handle(ctx, key, n)
+}
+
+func (n *NamespaceInformer) Filter(obj *corev1.Namespace) bool {
+	return true
+}
+
+func (n *NamespaceInformer) Add(ctx context.Context, key string, obj *corev1.Namespace) error {
+	n.namespaces.Set(key, obj)
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if _, ok := n.running[obj.Name]; ok {
+		return nil
+	}
+	informers, err := n.newInformers(ctx, obj.Name)
+	if err != nil {
+		n.logger.ErrorContext(ctx, "Unable to start informers", "target-namespace", obj.Name, "error", err)
+		return err
+	}
+	informersCtx, cancel := context.WithCancel(n.ctx)
+	running := runningInformers{
+		informers: informers,
+		cancel:    cancel,
+	}
+	n.running[obj.Name] = running
+	n.logger.InfoContext(ctx, "Starting informers", "target-namespace", obj.Name)
+	for _, ei := range running.informers {
+		if err := n.processor.StartInformer(ei, informersCtx.Done()); err != nil {
+			n.logger.ErrorContext(ctx, "Failed to start informer", "target-namespace", obj.Name, "error", err)
+			n.stop(ctx, obj.Name)
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *NamespaceInformer) Delete(ctx context.Context, key string) error {
+	n.namespaces.Delete(key)
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.stop(ctx, key)
+	return nil
+}
+
+func (n *NamespaceInformer) Update(ctx context.Context, key string, old, new *corev1.Namespace) error {
+	return n.Add(ctx, key, new)
+}
+
+func (n *NamespaceInformer) Reconcile(ctx context.Context, key string, new *corev1.Namespace) error {
+	return nil
+}
+
+func (n *NamespaceInformer) Cache() *Cache[*corev1.Namespace] {
+	return n.namespaces
+}
+
+func (n *NamespaceInformer) Equal(oldObj, newObj *corev1.Namespace) bool {
+	return true
+}
+
+func (n *NamespaceInformer) stop(ctx context.Context, namespace string) {
+	running, ok := n.running[namespace]
+	if !ok {
+		return
+	}
+	n.logger.InfoContext(ctx, "Stopping informers", "target-namespace", namespace)
+	for _, ei := range running.informers {
+		if err := n.processor.RemoveInformer(ei); err != nil {
+			n.logger.ErrorContext(ctx, "Failed to remove informer", "target-namespace", namespace, "error", err)
+		}
+	}
+	running.cancel()
+	delete(n.running, namespace)
+}
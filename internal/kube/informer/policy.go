@@ -0,0 +1,212 @@
+package informer
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ensureRequestPolicy maintains the approver-policy CertificateRequestPolicy
+// of the given certificate, those of the intermediates of the namespace for
+// CAs, and the Role letting cert-manager use the policies of its namespace,
+// before their requests are created.
+func (c *SkupperCertificateInformer) ensureRequestPolicy(ctx context.Context, obj *v2alpha1.Certificate) error {
+	config := certmgr.GetApproverPolicy()
+	if config == nil {
+		return nil
+	}
+	if !c.capabilities.ApproverPolicy {
+		c.logger.DebugContext(ctx, "Approver policies disabled by missing permissions", "key", obj.Key(), "feature", FeatureApproverPolicy)
+		return nil
+	}
+	desired := []*unstructured.Unstructured{certmgr.NewCertificateRequestPolicy(obj)}
+	if obj.Spec.Signing {
+		chain := certmgr.GetChain(obj.Namespace)
+		for i := range chain {
+			desired = append(desired, certmgr.NewIntermediatePolicy(obj.Namespace, chain, i))
+		}
+	}
+	log := c.logger.With("key", obj.Key())
+	for _, policy := range desired {
+		hash := certmgr.SpecHashOf(policy)
+		if applied, ok := c.policies.Get(policy.GetName()); ok && applied == hash {
+			continue
+		}
+		if err := applyRequestPolicy(ctx, c.cli, log, policy, obj.Namespace); err != nil {
+			return err
+		}
+		c.policies.Set(policy.GetName(), hash)
+	}
+	return c.ensurePolicyRole(ctx, obj.Namespace, config)
+}
+
+// removeRequestPolicy deletes the policy of a deleted certificate.
+func (c *SkupperCertificateInformer) removeRequestPolicy(ctx context.Context, obj *v2alpha1.Certificate) error {
+	config := certmgr.GetApproverPolicy()
+	if config == nil || !c.capabilities.ApproverPolicy {
+		return nil
+	}
+	name := certmgr.PolicyName(obj)
+	c.policies.Delete(name)
+	policiesCli := c.cli.Dynamic.Resource(certmgr.PolicyGVR)
+	current, err := policiesCli.Get(ctx, name, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && current.GetLabels()[certmgr.NamespaceLabel] == obj.Namespace {
+		c.logger.InfoContext(ctx, "Removing certificate request policy", "key", obj.Key(), "name", name)
+		if err = policiesCli.Delete(ctx, name, c.cli.DeleteOptions()); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "delete", current)
+	}
+	return c.ensurePolicyRole(ctx, obj.Namespace, config)
+}
+
+// ensurePolicyRole grants the cert-manager ServiceAccount the use of the
+// policies of the managed certificates of the namespace, and of its
+// intermediates when it holds CAs, removing the Role and RoleBinding once
+// there are none left. The policies of intermediates removed from the
+// configuration are left in place, like the intermediates.
+func (c *SkupperCertificateInformer) ensurePolicyRole(ctx context.Context, namespace string, config *certmgr.ApproverPolicy) error {
+	var policies []string
+	signing := false
+	for _, item := range c.informer.GetStore().List() {
+		cert := item.(*v2alpha1.Certificate)
+		if cert.Namespace == namespace && certmgr.IsManaged(cert) {
+			policies = append(policies, certmgr.PolicyName(cert))
+			signing = signing || cert.Spec.Signing
+		}
+	}
+	if signing {
+		for _, intermediate := range certmgr.GetChain(namespace) {
+			policies = append(policies, certmgr.IntermediatePolicyName(namespace, intermediate))
+		}
+	}
+	slices.Sort(policies)
+	cacheKey := namespace + "/" + certmgr.PolicyRoleName
+	if len(policies) == 0 {
+		c.policies.Delete(cacheKey)
+		return removePolicyRole(ctx, c.cli, c.logger, certmgr.PolicyRoleName, namespace)
+	}
+	role := certmgr.NewPolicyRole(certmgr.PolicyRoleName, namespace, policies)
+	binding := certmgr.NewPolicyRoleBinding(certmgr.PolicyRoleName, namespace, *config.ServiceAccount)
+	hash := certmgr.SpecHashOf(role) + certmgr.SpecHashOf(binding)
+	if applied, ok := c.policies.Get(cacheKey); ok && applied == hash {
+		return nil
+	}
+	if err := applyPolicyRole(ctx, c.cli, c.logger, role, binding); err != nil {
+		return err
+	}
+	c.policies.Set(cacheKey, hash)
+	return nil
+}
+
+// applyRequestPolicy creates or updates a policy generated for the given
+// namespace, leaving a policy of the same name generated for another
+// namespace unchanged.
+func applyRequestPolicy(ctx context.Context, cli *client.Client, log *slog.Logger, desired *unstructured.Unstructured, namespace string) error {
+	policiesCli := cli.Dynamic.Resource(certmgr.PolicyGVR)
+	current, err := policiesCli.Get(ctx, desired.GetName(), v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.InfoContext(ctx, "Creating certificate request policy", "name", desired.GetName())
+		if _, err = policiesCli.Create(ctx, desired, cli.CreateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "create", desired)
+	} else if err != nil {
+		return err
+	} else if current.GetLabels()[certmgr.NamespaceLabel] != namespace {
+		log.WarnContext(ctx, "Certificate request policy not generated for the namespace, leaving it unchanged", "name", desired.GetName(), "target-namespace", namespace)
+	} else if certmgr.SpecHashOf(current) != certmgr.SpecHashOf(desired) {
+		log.InfoContext(ctx, "Updating certificate request policy", "name", desired.GetName())
+		current.Object["spec"] = desired.Object["spec"]
+		current.SetAnnotations(desired.GetAnnotations())
+		if _, err = policiesCli.Update(ctx, current, cli.UpdateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "update", current)
+	}
+	return nil
+}
+
+// applyPolicyRole creates or updates the Role and RoleBinding letting the
+// cert-manager ServiceAccount use policies.
+func applyPolicyRole(ctx context.Context, cli *client.Client, log *slog.Logger, role *rbacv1.Role, binding *rbacv1.RoleBinding) error {
+	rolesCli := cli.Kube.RbacV1().Roles(role.Namespace)
+	bindingsCli := cli.Kube.RbacV1().RoleBindings(binding.Namespace)
+	current, err := rolesCli.Get(ctx, role.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.InfoContext(ctx, "Creating approver policy role", "target-namespace", role.Namespace, "name", role.Name)
+		if _, err = rolesCli.Create(ctx, role, cli.CreateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "create", role)
+	} else if err != nil {
+		return err
+	} else if certmgr.SpecHashOf(current) != certmgr.SpecHashOf(role) {
+		log.InfoContext(ctx, "Updating approver policy role", "target-namespace", role.Namespace, "name", role.Name)
+		current.Rules = role.Rules
+		v1.SetMetaDataAnnotation(&current.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(role))
+		if _, err = rolesCli.Update(ctx, current, cli.UpdateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "update", current)
+	}
+	currentBinding, err := bindingsCli.Get(ctx, binding.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.InfoContext(ctx, "Creating approver policy role binding", "target-namespace", binding.Namespace, "name", binding.Name)
+		if _, err = bindingsCli.Create(ctx, binding, cli.CreateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "create", binding)
+	} else if err != nil {
+		return err
+	} else if certmgr.SpecHashOf(currentBinding) != certmgr.SpecHashOf(binding) {
+		log.InfoContext(ctx, "Updating approver policy role binding", "target-namespace", binding.Namespace, "name", binding.Name)
+		currentBinding.Subjects = binding.Subjects
+		v1.SetMetaDataAnnotation(&currentBinding.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(binding))
+		if _, err = bindingsCli.Update(ctx, currentBinding, cli.UpdateOptions()); err != nil {
+			return err
+		}
+		cli.LogDryRun(ctx, "update", currentBinding)
+	}
+	return nil
+}
+
+// removePolicyRole deletes the Role and RoleBinding of the given name, if
+// they exist.
+func removePolicyRole(ctx context.Context, cli *client.Client, log *slog.Logger, name, namespace string) error {
+	bindingsCli := cli.Kube.RbacV1().RoleBindings(namespace)
+	currentBinding, err := bindingsCli.Get(ctx, name, v1.GetOptions{})
+	if err == nil {
+		log.InfoContext(ctx, "Removing approver policy role binding", "target-namespace", namespace, "name", name)
+		if err = bindingsCli.Delete(ctx, name, cli.DeleteOptions()); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		cli.LogDryRun(ctx, "delete", currentBinding)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	rolesCli := cli.Kube.RbacV1().Roles(namespace)
+	current, err := rolesCli.Get(ctx, name, v1.GetOptions{})
+	if err == nil {
+		log.InfoContext(ctx, "Removing approver policy role", "target-namespace", namespace, "name", name)
+		if err = rolesCli.Delete(ctx, name, cli.DeleteOptions()); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		cli.LogDryRun(ctx, "delete", current)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package informer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"skupper-cert-manager/internal/kube/client"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	DefaultReissueQPS   = 1
+	DefaultReissueBurst = 10
+)
+
+// ReissueLimiter spreads the reissuance of certificates over time, so that a
+// configuration change does not cause all certificates to be reissued at once.
+// A single limiter is meant to be shared by all informers.
+type ReissueLimiter struct {
+	mutex     sync.Mutex
+	limiter   *rate.Limiter
+	scheduled map[string]time.Time
+}
+
+func NewReissueLimiter(qps float64, burst int) *ReissueLimiter {
+	return &ReissueLimiter{
+		limiter:   rate.NewLimiter(rate.Limit(qps), burst),
+		scheduled: map[string]time.Time{},
+	}
+}
+
+// Reserve returns for how long the reissuance of the given key must still be
+// deferred. A slot is reserved on the first call, further calls for the same
+// key return the time left until the reserved slot.
+func (r *ReissueLimiter) Reserve(key string) time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if at, ok := r.scheduled[key]; ok {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+		delete(r.scheduled, key)
+		return 0
+	}
+	delay := r.limiter.Reserve().Delay()
+	if delay > 0 {
+		r.scheduled[key] = time.Now().Add(delay)
+	}
+	return delay
+}
+
+func (r *ReissueLimiter) Forget(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.scheduled, key)
+}
+
+// RequestReissue sets the Issuing condition on the given cert-manager
+// Certificate, which makes cert-manager issue a new certificate. On conflicts,
+// which are likely right after a spec update as cert-manager reacts to it, the
+// latest version is read back and the condition set again. The given cert is
+// never modified, so it is safe to pass objects owned by an informer store.
+func RequestReissue(ctx context.Context, cli *client.Client, cert *cm.Certificate, reason, message string) error {
+	certsCli := cli.CertManager.CertmanagerV1().Certificates(cert.Namespace)
+	current := cert.DeepCopy()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if current == nil {
+			latest, err := certsCli.Get(ctx, cert.Name, k8sv1.GetOptions{})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			current = latest
+		}
+		if !setIssuing(current, reason, message) {
+			return nil
+		}
+		_, err := certsCli.UpdateStatus(ctx, current, cli.UpdateOptions())
+		if err != nil {
+			current = nil
+			return err
+		}
+		cli.LogDryRun(ctx, "update status", current)
+		return nil
+	})
+}
+
+// setIssuing sets the Issuing condition on cert, reporting false when it is
+// already set.
+func setIssuing(cert *cm.Certificate, reason, message string) bool {
+	for _, condition := range cert.Status.Conditions {
+		if condition.Type == cm.CertificateConditionIssuing && condition.Status == metav1.ConditionTrue {
+			return false
+		}
+	}
+	now := k8sv1.Now()
+	issuing := cm.CertificateCondition{
+		Type:               cm.CertificateConditionIssuing,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: &now,
+		ObservedGeneration: cert.Generation,
+	}
+	replaced := false
+	for i, condition := range cert.Status.Conditions {
+		if condition.Type == cm.CertificateConditionIssuing {
+			cert.Status.Conditions[i] = issuing
+			replaced = true
+		}
+	}
+	if !replaced {
+		cert.Status.Conditions = append(cert.Status.Conditions, issuing)
+	}
+	return true
+}
+
+// sameIssuer compares two issuer references taking the defaults applied by
+// cert-manager into account.
+func sameIssuer(a, b metav1.ObjectReference) bool {
+	normalize := func(ref metav1.ObjectReference) metav1.ObjectReference {
+		if ref.Kind == "" {
+			ref.Kind = cm.IssuerKind
+		}
+		if ref.Group == "" {
+			ref.Group = cm.SchemeGroupVersion.Group
+		}
+		return ref
+	}
+	return normalize(a) == normalize(b)
+}
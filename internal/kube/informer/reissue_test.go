@@ -0,0 +1,71 @@
+package informer
+
+import (
+	"testing"
+	"time"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	metav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+)
+
+func TestReissueLimiterReserve(t *testing.T) {
+	limiter := NewReissueLimiter(1, 2)
+	if delay := limiter.Reserve("ns/a"); delay != 0 {
+		t.Errorf("first reservation delayed by %v", delay)
+	}
+	if delay := limiter.Reserve("ns/b"); delay != 0 {
+		t.Errorf("reservation within burst delayed by %v", delay)
+	}
+	delay := limiter.Reserve("ns/c")
+	if delay <= 0 || delay > time.Second {
+		t.Fatalf("reservation beyond burst delayed by %v, expected up to 1s", delay)
+	}
+	again := limiter.Reserve("ns/c")
+	if again <= 0 || again > delay {
+		t.Errorf("second call for a scheduled key returned %v, expected the time left of %v", again, delay)
+	}
+	limiter.Forget("ns/c")
+	if _, ok := limiter.scheduled["ns/c"]; ok {
+		t.Errorf("forgotten key still scheduled")
+	}
+}
+
+func TestSameIssuer(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b metav1.ObjectReference
+		same bool
+	}{
+		{"defaults", metav1.ObjectReference{Name: "ca"}, metav1.ObjectReference{Name: "ca", Kind: cm.IssuerKind, Group: "cert-manager.io"}, true},
+		{"different names", metav1.ObjectReference{Name: "ca"}, metav1.ObjectReference{Name: "other"}, false},
+		{"cluster issuer", metav1.ObjectReference{Name: "ca"}, metav1.ObjectReference{Name: "ca", Kind: cm.ClusterIssuerKind}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameIssuer(tt.a, tt.b); got != tt.same {
+				t.Errorf("sameIssuer() = %v, expected %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestSetIssuing(t *testing.T) {
+	cert := &cm.Certificate{}
+	cert.Status.Conditions = []cm.CertificateCondition{
+		{Type: cm.CertificateConditionIssuing, Status: metav1.ConditionFalse},
+		{Type: cm.CertificateConditionReady, Status: metav1.ConditionTrue},
+	}
+	if !setIssuing(cert, "IssuerChanged", "changed") {
+		t.Fatal("condition not set")
+	}
+	if len(cert.Status.Conditions) != 2 {
+		t.Fatalf("Issuing condition appended instead of replaced: %v", cert.Status.Conditions)
+	}
+	issuing := cert.Status.Conditions[0]
+	if issuing.Status != metav1.ConditionTrue || issuing.Reason != "IssuerChanged" || issuing.Message != "changed" {
+		t.Errorf("unexpected condition %+v", issuing)
+	}
+	if setIssuing(cert, "IssuerChanged", "changed") {
+		t.Error("condition set again while already issuing")
+	}
+}
@@ -0,0 +1,160 @@
+package informer
+
+import (
+	"context"
+	"slices"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ensureSharedRoot maintains the shared root CA and its ClusterIssuer. They
+// are left in place when the shared root is disabled, as removing the root
+// key would break the chains of all the CAs it signed.
+func (c *ConfigInformer) ensureSharedRoot(ctx context.Context) error {
+	config := certmgr.GetSharedRoot()
+	if config == nil {
+		return nil
+	}
+	if !c.capabilities.SharedRoot {
+		c.logger.WarnContext(ctx, "Shared root disabled by missing permissions, it must be created beforehand", "name", config.Name, "feature", FeatureSharedRoot)
+		return nil
+	}
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	policyConfig := certmgr.GetApproverPolicy()
+	if !c.capabilities.ApproverPolicy {
+		policyConfig = nil
+	}
+	if granted, err := c.sharedRootAccess(ctx, namespace, policyConfig != nil); !granted || err != nil {
+		return err
+	}
+	if policyConfig != nil {
+		if err := c.ensureSharedRootPolicy(ctx, *config, namespace, policyConfig); err != nil {
+			return err
+		}
+	}
+	issuersCli := c.cli.CertManager.CertmanagerV1().Issuers(namespace)
+	selfSigned := certmgr.NewSharedRootIssuer(*config, namespace)
+	if _, err := issuersCli.Get(ctx, selfSigned.Name, k8sv1.GetOptions{}); errors.IsNotFound(err) {
+		c.logger.InfoContext(ctx, "Creating shared root self-signed Issuer", "target-namespace", namespace, "name", selfSigned.Name)
+		if _, err = issuersCli.Create(ctx, selfSigned, c.cli.CreateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "create", selfSigned)
+	} else if err != nil {
+		return err
+	}
+	certsCli := c.cli.CertManager.CertmanagerV1().Certificates(namespace)
+	rootCert := certmgr.NewSharedRootCertificate(*config, namespace)
+	current, err := certsCli.Get(ctx, rootCert.Name, k8sv1.GetOptions{})
+	if errors.IsNotFound(err) {
+		c.logger.InfoContext(ctx, "Creating shared root CA", "target-namespace", namespace, "name", rootCert.Name)
+		if _, err = certsCli.Create(ctx, rootCert, c.cli.CreateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "create", rootCert)
+	} else if err != nil {
+		return err
+	} else if certmgr.SpecHashOf(current) != certmgr.SpecHashOf(rootCert) {
+		c.logger.InfoContext(ctx, "Updating shared root CA", "target-namespace", namespace, "name", rootCert.Name)
+		current.Spec = rootCert.Spec
+		k8sv1.SetMetaDataAnnotation(&current.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(rootCert))
+		if _, err = certsCli.Update(ctx, current, c.cli.UpdateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "update", current)
+	}
+	clusterIssuersCli := c.cli.CertManager.CertmanagerV1().ClusterIssuers()
+	clusterIssuer := certmgr.NewSharedRootClusterIssuer(*config)
+	currentIssuer, err := clusterIssuersCli.Get(ctx, clusterIssuer.Name, k8sv1.GetOptions{})
+	if errors.IsNotFound(err) {
+		c.logger.InfoContext(ctx, "Creating shared root ClusterIssuer", "name", clusterIssuer.Name)
+		if _, err = clusterIssuersCli.Create(ctx, clusterIssuer, c.cli.CreateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "create", clusterIssuer)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if certmgr.SpecHashOf(currentIssuer) != certmgr.SpecHashOf(clusterIssuer) {
+		c.logger.InfoContext(ctx, "Updating shared root ClusterIssuer", "name", clusterIssuer.Name)
+		currentIssuer.Spec = clusterIssuer.Spec
+		k8sv1.SetMetaDataAnnotation(&currentIssuer.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(clusterIssuer))
+		if _, err = clusterIssuersCli.Update(ctx, currentIssuer, c.cli.UpdateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "update", currentIssuer)
+	}
+	c.checkSharedRootReady(ctx, currentIssuer.Name, currentIssuer.Status.Conditions, namespace)
+	return nil
+}
+
+// ensureSharedRootPolicy maintains the approver-policy policy of the shared
+// root CA, and the Role letting cert-manager use it in its namespace, before
+// its requests are created.
+func (c *ConfigInformer) ensureSharedRootPolicy(ctx context.Context, config certmgr.SharedRoot, namespace string, policyConfig *certmgr.ApproverPolicy) error {
+	if err := applyRequestPolicy(ctx, c.cli, c.logger, certmgr.NewSharedRootPolicy(config, namespace), namespace); err != nil {
+		return err
+	}
+	role := certmgr.NewPolicyRole(certmgr.SharedRootPolicyRoleName, namespace, []string{certmgr.SharedRootPolicyName(config)})
+	binding := certmgr.NewPolicyRoleBinding(certmgr.SharedRootPolicyRoleName, namespace, *policyConfig.ServiceAccount)
+	return applyPolicyRole(ctx, c.cli, c.logger, role, binding)
+}
+
+// sharedRootAccess verifies the permissions needed in the namespace the
+// shared root is issued in, including those of its approver policy when
+// enabled. Namespaces granted are remembered, while missing permissions are
+// verified again on the next resync.
+func (c *ConfigInformer) sharedRootAccess(ctx context.Context, namespace string, approverPolicy bool) (bool, error) {
+	key := namespace
+	rules := SharedRootRules
+	if approverPolicy {
+		key += "/" + FeatureApproverPolicy
+	} else {
+		rules = slices.DeleteFunc(slices.Clone(rules), func(rule Rule) bool {
+			return rule.Feature == FeatureApproverPolicy
+		})
+	}
+	if granted, ok := c.rootAccess.Get(key); ok && granted {
+		return true, nil
+	}
+	missing, err := c.cli.MissingAccess(ctx, AccessFor(namespace, rules))
+	if err != nil {
+		return false, err
+	}
+	if len(missing) > 0 {
+		c.logger.WarnContext(ctx, "Shared root disabled by missing permissions in its namespace\n"+client.AccessTable(missing), "target-namespace", namespace, "feature", FeatureSharedRoot)
+		return false, nil
+	}
+	c.rootAccess.Set(key, true)
+	return true, nil
+}
+
+// checkSharedRootReady warns once about a shared root ClusterIssuer that is
+// not ready. ClusterIssuers read their Secret from the cert-manager cluster
+// resource namespace, so unless it is the namespace of the shared root, the
+// ClusterIssuer never gets ready and the CAs it should sign stay pending.
+func (c *ConfigInformer) checkSharedRootReady(ctx context.Context, name string, conditions []cm.IssuerCondition, namespace string) {
+	ready, message := GetIssuerReadyMessage(conditions)
+	if ready {
+		if c.rootProblem != "" {
+			c.logger.InfoContext(ctx, "Shared root ClusterIssuer ready", "name", name)
+		}
+		c.rootProblem = ""
+		return
+	}
+	if message == c.rootProblem {
+		return
+	}
+	c.rootProblem = message
+	c.logger.WarnContext(ctx, "Shared root ClusterIssuer not ready, cert-manager must be started with --cluster-resource-namespace set to the namespace of the shared root", "name", name, "target-namespace", namespace, "message", message)
+}
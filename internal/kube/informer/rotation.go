@@ -0,0 +1,300 @@
+package informer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"skupper-cert-manager/internal/certmgr"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ConditionTypeCARotation = "CARotation"
+	caRotationAnnotation    = "skupper.io/cert-manager-ca-rotation"
+	trustBundleKey          = "ca.crt"
+
+	caRotationRotating v2alpha1.StatusType = "Rotating"
+	caRotationComplete v2alpha1.StatusType = "Complete"
+)
+
+// rotationState is recorded on the trust bundle ConfigMap of a CA. Retiring
+// holds the fingerprints of the previous CAs, along with the time they stop
+// being trusted, which is zero until the certificates they signed are all
+// reissued. Published is when the current CA was added to the bundle.
+type rotationState struct {
+	Current   string               `json:"current"`
+	Published time.Time            `json:"published"`
+	Retiring  map[string]time.Time `json:"retiring,omitempty"`
+}
+
+// rotateCA publishes the trust bundle of a CA. When the CA is renewed, the
+// previous CA is kept in the bundle and, once the renewed CA has been
+// published for the publish delay, the certificates the CA signs are
+// reissued. The grace period of the previous CA only starts once they are
+// all reissued, so that reissues deferred by the ReissueLimiter do not leave
+// certificates it signed in use after it is removed from the bundle. The
+// bundle is only published through the ConfigMap, and trust-manager Bundles
+// when enabled: the Secrets of the certificates are left to cert-manager.
+func (c *SkupperCertificateInformer) rotateCA(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
+	enabled, grace := certmgr.GetCARotation(obj.Namespace)
+	if !enabled {
+		return nil
+	}
+	if !c.capabilities.CARotation {
+		c.logger.DebugContext(ctx, "CA rotation disabled by missing permissions", "key", key, "feature", FeatureCARotation)
+		return nil
+	}
+	secret, err := c.cli.Kube.CoreV1().Secrets(obj.Namespace).Get(ctx, obj.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ca, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		c.logger.DebugContext(ctx, "CA not issued yet", "key", key, "error", err)
+		return nil
+	}
+	fingerprint := certFingerprint(ca)
+	configMapsCli := c.cli.Kube.CoreV1().ConfigMaps(obj.Namespace)
+	bundle, err := configMapsCli.Get(ctx, certmgr.TrustBundleName(obj.Name), v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		bundle = newTrustBundle(obj)
+		writeTrustBundle(bundle, rotationState{Current: fingerprint, Published: time.Now()}, map[string]*x509.Certificate{fingerprint: ca})
+		c.logger.InfoContext(ctx, "Creating trust bundle", "key", key, "name", bundle.Name)
+		if _, err = configMapsCli.Create(ctx, bundle, c.cli.CreateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "create", bundle)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	state, certs := readTrustBundle(bundle)
+	now := time.Now()
+	renewed := false
+	if state.Current != fingerprint {
+		if _, ok := certs[state.Current]; ok {
+			state.Retiring[state.Current] = time.Time{}
+		}
+		c.logger.InfoContext(ctx, "CA renewed, trusting previous CA until the certificates it signed are reissued", "key", key)
+		state.Current = fingerprint
+		state.Published = now
+		certs[fingerprint] = ca
+		renewed = true
+	}
+	if !renewed && len(state.Retiring) == 0 {
+		return nil
+	}
+	// reissues wait for the renewed CA to be observed published in a later
+	// reconcile, after the publish delay
+	publishedAt := state.Published.Add(certmgr.GetCAPublishDelay(obj.Namespace))
+	reissue := !renewed && now.After(publishedAt)
+	reissued, total, err := c.syncSignedCertificates(ctx, obj, ca, reissue)
+	if err != nil {
+		return err
+	}
+	started, retired := retireCAs(&state, certs, total-reissued, grace, now)
+	for _, fingerprint := range started {
+		c.logger.InfoContext(ctx, "Certificates reissued, trusting previous CA during the grace period", "key", key, "fingerprint", fingerprint, "grace-period", grace)
+	}
+	for _, fingerprint := range retired {
+		c.logger.InfoContext(ctx, "Grace period over, retiring previous CA", "key", key, "fingerprint", fingerprint)
+	}
+	if renewed || len(started) > 0 || len(retired) > 0 {
+		writeTrustBundle(bundle, state, certs)
+		if bundle, err = configMapsCli.Update(ctx, bundle, c.cli.UpdateOptions()); err != nil {
+			return err
+		}
+		c.cli.LogDryRun(ctx, "update", bundle)
+	}
+	condition := v2alpha1.ConditionState{
+		Status:  v1.ConditionFalse,
+		Reason:  caRotationComplete,
+		Message: "Previous CA retired",
+	}
+	if len(state.Retiring) > 0 {
+		var until time.Time
+		waiting := false
+		for _, at := range state.Retiring {
+			if at.IsZero() {
+				waiting = true
+			} else if at.After(until) {
+				until = at
+			}
+		}
+		message := fmt.Sprintf("%d of %d certificates signed by the renewed CA, previous CA trusted until %s", reissued, total, until.Format(time.RFC3339))
+		if waiting {
+			message = fmt.Sprintf("%d of %d certificates signed by the renewed CA, previous CA trusted until they are all reissued", reissued, total)
+		}
+		if !reissue && reissued < total {
+			message += fmt.Sprintf(", reissues start at %s", publishedAt.Format(time.RFC3339))
+		}
+		condition = v2alpha1.ConditionState{
+			Status:  v1.ConditionTrue,
+			Reason:  caRotationRotating,
+			Message: message,
+		}
+	}
+	return UpdateSkupperCertificateStatus(ctx, c.cli, obj, func(status *v2alpha1.CertificateStatus, generation int64) bool {
+		return status.SetCondition(ConditionTypeCARotation, condition, generation)
+	})
+}
+
+// retireCAs starts the grace period of the previous CAs once no certificate
+// is pending reissuance, and removes those whose grace period is over from
+// the trusted CAs. It returns the fingerprints of the CAs whose grace period
+// started, and of those removed.
+func retireCAs(state *rotationState, certs map[string]*x509.Certificate, pending int, grace time.Duration, now time.Time) ([]string, []string) {
+	var started, retired []string
+	for fingerprint, at := range state.Retiring {
+		switch {
+		case at.IsZero() && pending == 0:
+			state.Retiring[fingerprint] = now.Add(grace)
+			started = append(started, fingerprint)
+		case !at.IsZero() && now.After(at):
+			delete(state.Retiring, fingerprint)
+			delete(certs, fingerprint)
+			retired = append(retired, fingerprint)
+		}
+	}
+	return started, retired
+}
+
+// syncSignedCertificates counts the certificates signed by the given CA and,
+// when reissue is set, requests the reissuance of those not signed by it yet.
+// It returns how many certificates are signed by it, out of the total.
+func (c *SkupperCertificateInformer) syncSignedCertificates(ctx context.Context, obj *v2alpha1.Certificate, ca *x509.Certificate, reissue bool) (int, int, error) {
+	reissued, total := 0, 0
+	secretsCli := c.cli.Kube.CoreV1().Secrets(obj.Namespace)
+	for _, item := range c.informer.GetStore().List() {
+		leaf := item.(*v2alpha1.Certificate)
+		if leaf.Namespace != obj.Namespace || leaf.Spec.Ca != obj.Name || !certmgr.IsManaged(leaf) {
+			continue
+		}
+		total++
+		secret, err := secretsCli.Get(ctx, leaf.Name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return reissued, total, err
+		}
+		if cert, err := parseCertificate(secret.Data[corev1.TLSCertKey]); err == nil && cert.CheckSignatureFrom(ca) == nil {
+			reissued++
+			continue
+		}
+		if !reissue {
+			continue
+		}
+		if err = c.reissueSignedCertificate(ctx, leaf); err != nil {
+			return reissued, total, err
+		}
+	}
+	return reissued, total, nil
+}
+
+func (c *SkupperCertificateInformer) reissueSignedCertificate(ctx context.Context, leaf *v2alpha1.Certificate) error {
+	key := leaf.Key()
+	if !c.capabilities.Reissue {
+		c.logger.WarnContext(ctx, "CA renewed, reissue disabled by missing permissions", "key", key, "feature", FeatureReissue)
+		return nil
+	}
+	if delay := c.reissues.Reserve(key); delay > 0 {
+		c.logger.DebugContext(ctx, "CA renewed, deferring reissue", "key", key, "delay", delay)
+		return nil
+	}
+	cmCert, err := c.cli.CertManager.CertmanagerV1().Certificates(leaf.Namespace).Get(ctx, leaf.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.reissues.Forget(key)
+	c.logger.InfoContext(ctx, "CA renewed, requesting reissue", "key", key, "ca", leaf.Spec.Ca)
+	return RequestReissue(ctx, c.cli, cmCert, "CARenewed", fmt.Sprintf("CA %q has been renewed", leaf.Spec.Ca))
+}
+
+func newTrustBundle(obj *v2alpha1.Certificate) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      certmgr.TrustBundleName(obj.Name),
+			Namespace: obj.Namespace,
+			OwnerReferences: []v1.OwnerReference{
+				*v1.NewControllerRef(obj, v2alpha1.SchemeGroupVersion.WithKind("Certificate")),
+			},
+		},
+	}
+}
+
+func readTrustBundle(bundle *corev1.ConfigMap) (rotationState, map[string]*x509.Certificate) {
+	state := rotationState{}
+	_ = json.Unmarshal([]byte(bundle.Annotations[caRotationAnnotation]), &state)
+	if state.Retiring == nil {
+		state.Retiring = map[string]time.Time{}
+	}
+	certs := map[string]*x509.Certificate{}
+	rest := []byte(bundle.Data[trustBundleKey])
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs[certFingerprint(cert)] = cert
+		}
+	}
+	return state, certs
+}
+
+// writeTrustBundle records the state and the trusted CAs, the current CA
+// first.
+func writeTrustBundle(bundle *corev1.ConfigMap, state rotationState, certs map[string]*x509.Certificate) {
+	data, _ := json.Marshal(state)
+	v1.SetMetaDataAnnotation(&bundle.ObjectMeta, caRotationAnnotation, string(data))
+	fingerprints := make([]string, 0, len(certs))
+	for fingerprint := range certs {
+		if fingerprint != state.Current {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+	slices.Sort(fingerprints)
+	fingerprints = append([]string{state.Current}, fingerprints...)
+	var pemData strings.Builder
+	for _, fingerprint := range fingerprints {
+		_ = pem.Encode(&pemData, &pem.Block{Type: "CERTIFICATE", Bytes: certs[fingerprint].Raw})
+	}
+	if bundle.Data == nil {
+		bundle.Data = map[string]string{}
+	}
+	bundle.Data[trustBundleKey] = pemData.String()
+}
+
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,164 @@
+package informer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCACertificate(t *testing.T, name string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestTrustBundleRoundTrip(t *testing.T) {
+	previous := newCACertificate(t, "previous")
+	current := newCACertificate(t, "current")
+	published := time.Now().Truncate(time.Second).UTC()
+	retiringAt := published.Add(time.Hour)
+	state := rotationState{
+		Current:   certFingerprint(current),
+		Published: published,
+		Retiring:  map[string]time.Time{certFingerprint(previous): retiringAt},
+	}
+	bundle := &corev1.ConfigMap{}
+	writeTrustBundle(bundle, state, map[string]*x509.Certificate{
+		certFingerprint(previous): previous,
+		certFingerprint(current):  current,
+	})
+
+	if got := strings.Count(bundle.Data[trustBundleKey], "BEGIN CERTIFICATE"); got != 2 {
+		t.Fatalf("bundle holds %d certificates, want 2", got)
+	}
+	_, first := readTrustBundle(&corev1.ConfigMap{Data: map[string]string{
+		trustBundleKey: bundle.Data[trustBundleKey][:strings.Index(bundle.Data[trustBundleKey], "-----END CERTIFICATE-----")+len("-----END CERTIFICATE-----")],
+	}})
+	if _, ok := first[certFingerprint(current)]; !ok {
+		t.Error("current CA is not first in the bundle")
+	}
+
+	gotState, certs := readTrustBundle(bundle)
+	if gotState.Current != state.Current {
+		t.Errorf("Current = %q, want %q", gotState.Current, state.Current)
+	}
+	if !gotState.Published.Equal(published) {
+		t.Errorf("Published = %v, want %v", gotState.Published, published)
+	}
+	if at, ok := gotState.Retiring[certFingerprint(previous)]; !ok || !at.Equal(retiringAt) {
+		t.Errorf("Retiring = %v, want %v", gotState.Retiring, state.Retiring)
+	}
+	if len(certs) != 2 || certs[certFingerprint(previous)] == nil || certs[certFingerprint(current)] == nil {
+		t.Errorf("certificates = %v, want previous and current", certs)
+	}
+}
+
+func TestReadTrustBundleEmpty(t *testing.T) {
+	state, certs := readTrustBundle(&corev1.ConfigMap{})
+	if state.Current != "" || state.Retiring == nil || len(certs) != 0 {
+		t.Errorf("readTrustBundle() = %v, %v, want an empty state", state, certs)
+	}
+}
+
+func TestRetireCAs(t *testing.T) {
+	previous := certFingerprint(newCACertificate(t, "previous"))
+	now := time.Now()
+	grace := time.Hour
+	tests := []struct {
+		name     string
+		at       time.Time
+		pending  int
+		want     time.Time
+		started  bool
+		retained bool
+	}{
+		{name: "reissues pending", pending: 2, retained: true},
+		{name: "reissues complete", want: now.Add(grace), started: true, retained: true},
+		{name: "grace period running", at: now.Add(time.Minute), want: now.Add(time.Minute), retained: true},
+		{name: "grace period running with new pending certificates", at: now.Add(time.Minute), pending: 1, want: now.Add(time.Minute), retained: true},
+		{name: "grace period over", at: now.Add(-time.Minute)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := rotationState{Current: "current", Retiring: map[string]time.Time{previous: test.at}}
+			certs := map[string]*x509.Certificate{previous: {}, "current": {}}
+			started, retired := retireCAs(&state, certs, test.pending, grace, now)
+			if (len(started) > 0) != test.started {
+				t.Errorf("started = %v, want %v", started, test.started)
+			}
+			if _, ok := certs[previous]; ok != test.retained || (len(retired) == 0) != test.retained {
+				t.Fatalf("previous CA retained = %v, retired = %v, want retained %v", ok, retired, test.retained)
+			}
+			if at := state.Retiring[previous]; test.retained && !at.Equal(test.want) {
+				t.Errorf("previous CA trusted until %v, want %v", at, test.want)
+			}
+		})
+	}
+}
+
+// TestDeferredReissueKeepsPreviousCA shows that a reissue deferred by the
+// ReissueLimiter leaves the certificate pending, which keeps the grace
+// period of the previous CA from starting however long the rotation takes.
+func TestDeferredReissueKeepsPreviousCA(t *testing.T) {
+	limiter := NewReissueLimiter(0.001, 1)
+	limiter.Reserve("ns1/other")
+	c := &SkupperCertificateInformer{
+		reissues:     limiter,
+		capabilities: Capabilities{Reissue: true},
+		logger:       slog.New(slog.DiscardHandler),
+	}
+	leaf := &v2alpha1.Certificate{
+		ObjectMeta: v1.ObjectMeta{Name: "skupper-site-server", Namespace: "ns1"},
+		Spec:       v2alpha1.CertificateSpec{Ca: "skupper-site-ca"},
+	}
+	if err := c.reissueSignedCertificate(context.Background(), leaf); err != nil {
+		t.Fatal(err)
+	}
+	if _, deferred := limiter.scheduled[leaf.Key()]; !deferred {
+		t.Fatal("reissue not deferred by the limiter")
+	}
+
+	previous := certFingerprint(newCACertificate(t, "previous"))
+	state := rotationState{Current: "current", Retiring: map[string]time.Time{previous: {}}}
+	certs := map[string]*x509.Certificate{previous: {}, "current": {}}
+	for _, now := range []time.Time{time.Now(), time.Now().Add(24 * time.Hour), time.Now().Add(365 * 24 * time.Hour)} {
+		if started, retired := retireCAs(&state, certs, 1, time.Hour, now); len(started) > 0 || len(retired) > 0 {
+			t.Fatalf("previous CA retiring at %v with a deferred reissue: started %v, retired %v", now, started, retired)
+		}
+	}
+	if _, ok := certs[previous]; !ok {
+		t.Error("previous CA removed from the bundle")
+	}
+}
@@ -2,93 +2,139 @@ package informer
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
-	"reflect"
+	"time"
 
 	"skupper-cert-manager/internal/certmgr"
 	"skupper-cert-manager/internal/kube/client"
 	"skupper-cert-manager/internal/logger"
 
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
 	informerv2alpha1 "github.com/skupperproject/skupper/pkg/generated/client/informers/externalversions/skupper/v2alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
 const (
-	controllerKey  = "certificate-controller"
-	controllerName = "cert-manager"
+	skupperInformerName = "informer.skupper"
 )
 
-func NewSkupperCertificateInformer(cli *client.Client, namespace string) *SkupperCertificateInformer {
+func NewSkupperCertificateInformer(cli *client.Client, namespace string, opts Options) *SkupperCertificateInformer {
 	res := &SkupperCertificateInformer{
-		informer:     informerv2alpha1.NewCertificateInformer(cli.Skupper, namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
-		certificates: map[string]*v2alpha1.Certificate{},
-		cli:          cli,
-		logger:       logger.NewLogger("informer.skupper", namespace),
+		informer:        informerv2alpha1.NewCertificateInformer(cli.Skupper, namespace, opts.ResyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}),
+		certificates:    NewCache[*v2alpha1.Certificate](),
+		hashes:          NewCache[string](),
+		bundles:         NewCache[string](),
+		policies:        NewCache[string](),
+		chains:          NewCache[string](),
+		caHashes:        NewCache[string](),
+		pendingReissues: NewCache[string](),
+		adoptions:       NewCache[time.Time](),
+		reissues:        opts.Reissues,
+		capabilities:    opts.Capabilities,
+		cli:             cli,
+		logger:          logger.NewLogger(skupperInformerName, namespace),
 	}
 	return res
 }
 
 type SkupperCertificateInformer struct {
-	informer     cache.SharedIndexInformer
-	certificates map[string]*v2alpha1.Certificate
-	logger       *slog.Logger
-	cli          *client.Client
+	informer        cache.SharedIndexInformer
+	certificates    *Cache[*v2alpha1.Certificate]
+	hashes          *Cache[string]
+	bundles         *Cache[string]
+	policies        *Cache[string]
+	chains          *Cache[string]
+	caHashes        *Cache[string]
+	pendingReissues *Cache[string]
+	adoptions       *Cache[time.Time]
+	reissues        *ReissueLimiter
+	capabilities    Capabilities
+	logger          *slog.Logger
+	cli             *client.Client
 }
 
-func (c *SkupperCertificateInformer) Handle(key string) error {
-	return Handle(key, c)
+func (c *SkupperCertificateInformer) Name() string {
+	return skupperInformerName
+}
+
+func (c *SkupperCertificateInformer) Handle(ctx context.Context, key string) error {
+	return Handle(ctx, key, c)
 }
 
 func (c *SkupperCertificateInformer) Filter(obj *v2alpha1.Certificate) bool {
-	if name, ok := obj.Spec.Settings[controllerKey]; ok {
-		return name == controllerName
-	}
-	return false
+	return certmgr.IsManaged(obj)
 }
 
-func (c *SkupperCertificateInformer) Add(key string, obj *v2alpha1.Certificate) error {
-	return c.Reconcile(key, obj)
+func (c *SkupperCertificateInformer) Add(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
+	return c.Reconcile(ctx, key, obj)
 }
 
-func (c *SkupperCertificateInformer) Delete(key string) error {
-	_, ok := c.certificates[key]
+func (c *SkupperCertificateInformer) Delete(ctx context.Context, key string) error {
+	obj, ok := c.certificates.Get(key)
 	if !ok {
 		return nil
 	}
-	c.logger.Info("Certificate has been deleted", "key", key)
-	delete(c.certificates, key)
+	c.logger.InfoContext(ctx, "Certificate has been deleted", "key", key)
+	c.certificates.Delete(key)
+	c.hashes.Delete(key)
+	c.caHashes.Delete(key)
+	c.pendingReissues.Delete(key)
+	c.adoptions.Delete(key)
+	c.reissues.Forget(key)
+	if err := c.removeRequestPolicy(ctx, obj); err != nil {
+		return err
+	}
+	if obj.Spec.Signing {
+		return c.removeTrustBundle(ctx, obj)
+	}
 	return nil
 }
 
-func (c *SkupperCertificateInformer) Update(key string, old, new *v2alpha1.Certificate) error {
-	return c.Reconcile(key, new)
+func (c *SkupperCertificateInformer) Update(ctx context.Context, key string, old, new *v2alpha1.Certificate) error {
+	return c.Reconcile(ctx, key, new)
 }
 
-func (c *SkupperCertificateInformer) Reconcile(key string, obj *v2alpha1.Certificate) error {
+func (c *SkupperCertificateInformer) Reconcile(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
 	var err error
-	if err = c.createRootIssuer(obj.Namespace); err != nil {
+	if err = c.createRootIssuer(ctx, obj.Namespace); err != nil {
+		return err
+	}
+	if err = c.ensureRequestPolicy(ctx, obj); err != nil {
 		return err
 	}
 	if obj.Spec.Signing {
-		if err = c.ensureCACert(key, obj); err != nil {
+		if err = c.ensureChain(ctx, obj.Namespace); err != nil {
+			return err
+		}
+		if err = c.ensureCACert(ctx, key, obj); err != nil {
+			return err
+		}
+		if err = c.ensureIssuerFor(ctx, obj); err != nil {
+			return err
+		}
+		if err = c.rotateCA(ctx, key, obj); err != nil {
 			return err
 		}
-		return c.ensureIssuerFor(obj)
+		return c.ensureTrustBundle(ctx, obj)
 	}
-	if err = c.ensureNoIssuerFor(obj); err != nil {
+	if err = c.ensureNoIssuerFor(ctx, obj); err != nil {
 		return err
 	}
-	return c.createCertificateFor(key, obj)
+	return c.createCertificateFor(ctx, key, obj)
 }
 
-func (c *SkupperCertificateInformer) Cache() map[string]*v2alpha1.Certificate {
+func (c *SkupperCertificateInformer) Cache() *Cache[*v2alpha1.Certificate] {
 	return c.certificates
 }
 
+// Equal compares the spec hash last handled for the certificate with the one
+// computed for newObj, so configuration changes are detected as well.
 func (c *SkupperCertificateInformer) Equal(olbObj, newObj *v2alpha1.Certificate) bool {
-	return reflect.DeepEqual(olbObj.Spec, newObj.Spec)
+	return c.isHandled(newObj.Key(), newObj)
 }
 
 func (c *SkupperCertificateInformer) Spec(obj *v2alpha1.Certificate) v2alpha1.CertificateSpec {
@@ -99,117 +145,251 @@ func (c *SkupperCertificateInformer) Informer() cache.SharedIndexInformer {
 	return c.informer
 }
 
-func (c *SkupperCertificateInformer) ensureCACert(key string, obj *v2alpha1.Certificate) error {
+func (c *SkupperCertificateInformer) ensureCACert(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
 	var err error
-	if currentCert, ok := c.certificates[key]; ok {
-		if reflect.DeepEqual(obj.Spec, currentCert.Spec) {
-			return nil
-		}
+	caCert := certmgr.NewCACertificate(obj, certmgr.NewNameConstraints(obj, c.signedHosts(obj)))
+	if c.isHandled(key, obj) && c.isCAHandled(key, caCert) && !c.adoptionDue(key) {
+		return nil
 	}
-	caCert := certmgr.NewCACertificate(obj)
 	certsCli := c.cli.CertManager.CertmanagerV1().Certificates(obj.Namespace)
-	c.logger.Debug("Loading cert-manager CA certificate", "key", key)
-	currentCmCaCert, err := certsCli.Get(context.Background(), obj.Name, v1.GetOptions{})
+	c.logger.DebugContext(ctx, "Loading cert-manager CA certificate", "key", key)
+	currentCmCaCert, err := certsCli.Get(ctx, obj.Name, v1.GetOptions{})
 	if err == nil {
-		if !reflect.DeepEqual(currentCmCaCert.Spec, caCert.Spec) {
-			c.logger.Info("Updating existing CA certificate", "key", key)
-			currentCmCaCert.Spec = caCert.Spec
-			_, err = certsCli.Update(context.Background(), currentCmCaCert, v1.UpdateOptions{})
-			if err != nil {
-				c.logger.Error("Failed to update existing CA certificate", "key", key, "error", err)
+		if certmgr.SpecHashOf(currentCmCaCert) == certmgr.SpecHashOf(caCert) {
+			c.logger.DebugContext(ctx, "CA certificate already handled", "key", key)
+			if err = c.retryPendingReissue(ctx, key, currentCmCaCert); err != nil {
 				return err
 			}
-			c.logger.Info("Updated CA certificate", "key", key)
-			c.certificates[key] = obj
+			c.caHandled(key, obj, caCert)
+			return nil
 		}
-	} else {
-		c.logger.Info("Creating cert-manager CA certificate", "key", key)
-		_, err = certsCli.Create(context.Background(), caCert, v1.CreateOptions{})
-		if err != nil {
-			c.logger.Error("Failed to create cert-manager CA certificate", "key", key, "error", err)
+		c.logger.InfoContext(ctx, "Updating existing CA certificate", "key", key)
+		if err = c.updateCertificate(ctx, key, currentCmCaCert, caCert); err != nil {
+			if !client.IsRequeueAfter(err) {
+				c.logger.ErrorContext(ctx, "Failed to update existing CA certificate", "key", key, "error", err)
+			}
 			return err
 		}
-		c.certificates[key] = obj
-		if err = SkupperCertificateReadyOrPending(c.cli, obj, false, "Pending"); err != nil {
-			c.logger.Error("Failed to set CA certificate as configured", "key", key, "error", err)
-			return err
+		c.logger.InfoContext(ctx, "Updated CA certificate", "key", key)
+		c.caHandled(key, obj, caCert)
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		c.logger.ErrorContext(ctx, "Failed to load cert-manager CA certificate", "key", key, "error", err)
+		return err
+	}
+	if adopted, err := c.adoptCACert(ctx, key, obj); adopted || err != nil {
+		if adopted {
+			c.caHashes.Set(key, certmgr.SpecHashOf(caCert))
 		}
+		return err
+	}
+	c.logger.InfoContext(ctx, "Creating cert-manager CA certificate", "key", key)
+	_, err = certsCli.Create(ctx, caCert, c.cli.CreateOptions())
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to create cert-manager CA certificate", "key", key, "error", err)
+		return err
+	}
+	c.cli.LogDryRun(ctx, "create", caCert)
+	c.caHandled(key, obj, caCert)
+	if err = c.adoptionEnded(ctx, key, obj); err != nil {
+		return err
+	}
+	if err = SkupperCertificateReadyOrPending(ctx, c.cli, obj, false, "Pending"); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to set CA certificate as configured", "key", key, "error", err)
+		return err
 	}
 	return nil
 }
 
-func (c *SkupperCertificateInformer) ensureIssuerFor(obj *v2alpha1.Certificate) error {
+func (c *SkupperCertificateInformer) ensureIssuerFor(ctx context.Context, obj *v2alpha1.Certificate) error {
 	key, _ := cache.MetaNamespaceKeyFunc(obj)
+	issuer := certmgr.NewIssuer(obj)
 	issuersCli := c.cli.CertManager.CertmanagerV1().Issuers(obj.Namespace)
-	_, err := issuersCli.Get(context.Background(), obj.Name, v1.GetOptions{})
+	current, err := issuersCli.Get(ctx, obj.Name, v1.GetOptions{})
 	if err == nil {
-		c.logger.Debug("Issuer already exists", "key", key)
+		if certmgr.SpecHashOf(current) == certmgr.SpecHashOf(issuer) {
+			c.logger.DebugContext(ctx, "Issuer already exists", "key", key)
+			return nil
+		}
+		c.logger.InfoContext(ctx, "Updating Issuer", "key", key)
+		current.Spec = issuer.Spec
+		v1.SetMetaDataAnnotation(&current.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(issuer))
+		_, err = issuersCli.Update(ctx, current, c.cli.UpdateOptions())
+		if err != nil {
+			c.logger.ErrorContext(ctx, "Failed to update issuer", "key", key, "error", err)
+			return err
+		}
+		c.cli.LogDryRun(ctx, "update", current)
 		return nil
 	}
-	issuer := certmgr.NewIssuer(obj)
-	c.logger.Info("Creating Issuer", "key", key)
-	_, err = issuersCli.Create(context.Background(), issuer, v1.CreateOptions{})
+	if !errors.IsNotFound(err) {
+		c.logger.ErrorContext(ctx, "Failed to load issuer", "key", key, "error", err)
+		return err
+	}
+	c.logger.InfoContext(ctx, "Creating Issuer", "key", key)
+	_, err = issuersCli.Create(ctx, issuer, c.cli.CreateOptions())
 	if err != nil {
-		c.logger.Error("Failed to create issuer", "key", key, "error", err)
+		c.logger.ErrorContext(ctx, "Failed to create issuer", "key", key, "error", err)
+		return err
 	}
-	return err
+	c.cli.LogDryRun(ctx, "create", issuer)
+	return nil
 }
 
-func (c *SkupperCertificateInformer) createRootIssuer(namespace string) error {
+func (c *SkupperCertificateInformer) createRootIssuer(ctx context.Context, namespace string) error {
 	if !c.needsRootIssuer(namespace) {
-		c.logger.Debug("Skipping root issuer creation", "target-namespace", namespace)
+		c.logger.DebugContext(ctx, "Skipping root issuer creation", "target-namespace", namespace)
 		return nil
 	}
 	rootIssuer := certmgr.NewRootIssuer(namespace)
 	issuersCli := c.cli.CertManager.CertmanagerV1().Issuers(namespace)
-	_, err := issuersCli.Get(context.Background(), certmgr.DefaultRootIssuerName, v1.GetOptions{})
+	_, err := issuersCli.Get(ctx, certmgr.DefaultRootIssuerName, v1.GetOptions{})
 	if err == nil {
-		c.logger.Debug("Root Issuer already exists", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName)
+		c.logger.DebugContext(ctx, "Root Issuer already exists", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName)
 		return nil
 	}
-	c.logger.Info("Creating Root Issuer", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName)
-	_, err = issuersCli.Create(context.Background(), rootIssuer, v1.CreateOptions{})
+	c.logger.InfoContext(ctx, "Creating Root Issuer", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName)
+	_, err = issuersCli.Create(ctx, rootIssuer, c.cli.CreateOptions())
 	if err != nil {
-		c.logger.Error("Failed to create root issuer", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName, "error", err)
+		c.logger.ErrorContext(ctx, "Failed to create root issuer", "target-namespace", namespace, "name", certmgr.DefaultRootIssuerName, "error", err)
 		return err
 	}
+	c.cli.LogDryRun(ctx, "create", rootIssuer)
 	return nil
 }
 
-func (c *SkupperCertificateInformer) createCertificateFor(key string, obj *v2alpha1.Certificate) error {
-	if currentCert, ok := c.certificates[key]; ok {
-		if reflect.DeepEqual(obj.Spec, currentCert.Spec) {
-			return nil
-		}
+func (c *SkupperCertificateInformer) createCertificateFor(ctx context.Context, key string, obj *v2alpha1.Certificate) error {
+	if c.isHandled(key, obj) && !c.adoptionDue(key) {
+		return nil
 	}
 	desired := certmgr.NewCertificate(obj)
 	certsCli := c.cli.CertManager.CertmanagerV1().Certificates(obj.Namespace)
-	current, err := certsCli.Get(context.Background(), obj.Name, v1.GetOptions{})
+	current, err := certsCli.Get(ctx, obj.Name, v1.GetOptions{})
 	if err == nil {
-		c.logger.Debug("Certificate already exists", "key", key)
-		if !reflect.DeepEqual(current.Spec, desired.Spec) {
-			c.logger.Debug("Updating existing certificate", "key", key)
-			current.Spec = desired.Spec
-			_, err = certsCli.Update(context.Background(), current, v1.UpdateOptions{})
-			if err != nil {
-				c.logger.Error("Failed to update existing certificate", "key", key, "error", err)
+		c.logger.DebugContext(ctx, "Certificate already exists", "key", key)
+		if certmgr.SpecHashOf(current) != certmgr.SpecHashOf(desired) {
+			c.logger.DebugContext(ctx, "Updating existing certificate", "key", key)
+			if err = c.updateCertificate(ctx, key, current, desired); err != nil {
+				if !client.IsRequeueAfter(err) {
+					c.logger.ErrorContext(ctx, "Failed to update existing certificate", "key", key, "error", err)
+				}
 				return err
 			}
-			c.certificates[key] = obj
+		} else if err = c.retryPendingReissue(ctx, key, current); err != nil {
+			return err
 		}
+		c.handled(key, obj)
 		return nil
 	}
-	c.logger.Info("Creating Certificate", "key", key)
-	_, err = certsCli.Create(context.Background(), desired, v1.CreateOptions{})
+	if !errors.IsNotFound(err) {
+		c.logger.ErrorContext(ctx, "Failed to load certificate", "key", key, "error", err)
+		return err
+	}
+	if adopted, err := c.adoptCertificate(ctx, key, obj); adopted || err != nil {
+		return err
+	}
+	c.logger.InfoContext(ctx, "Creating Certificate", "key", key)
+	_, err = certsCli.Create(ctx, desired, c.cli.CreateOptions())
 	if err != nil {
-		c.logger.Error("Failed to create certificate", "key", key, "error", err)
+		c.logger.ErrorContext(ctx, "Failed to create certificate", "key", key, "error", err)
+		return err
+	}
+	c.cli.LogDryRun(ctx, "create", desired)
+	c.handled(key, obj)
+	if err = c.adoptionEnded(ctx, key, obj); err != nil {
+		return err
+	}
+	if err = SkupperCertificateReadyOrPending(ctx, c.cli, obj, false, "Pending"); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to set certificate as configured", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// updateCertificate brings an existing cert-manager Certificate in line with
+// the desired one. When the resolved issuer has changed, the update is subject
+// to the reissue rate limit and cert-manager is asked to reissue the
+// certificate using the new issuer.
+func (c *SkupperCertificateInformer) updateCertificate(ctx context.Context, key string, current, desired *cm.Certificate) error {
+	issuerChanged := !sameIssuer(current.Spec.IssuerRef, desired.Spec.IssuerRef)
+	if issuerChanged {
+		if delay := c.reissues.Reserve(key); delay > 0 {
+			c.logger.InfoContext(ctx, "Issuer has changed, deferring reissue", "key", key, "delay", delay)
+			return client.RequeueAfter(delay, "reissue rate limit")
+		}
 	}
-	c.certificates[key] = obj
-	if err = SkupperCertificateReadyOrPending(c.cli, obj, false, "Pending"); err != nil {
-		c.logger.Error("Failed to set certificate as configured", "key", key, "error", err)
+	from := current.Spec.IssuerRef
+	current.Spec = desired.Spec
+	v1.SetMetaDataAnnotation(&current.ObjectMeta, certmgr.SpecHashAnnotation, certmgr.SpecHashOf(desired))
+	certsCli := c.cli.CertManager.CertmanagerV1().Certificates(current.Namespace)
+	updated, err := certsCli.Update(ctx, current, c.cli.UpdateOptions())
+	if err != nil {
 		return err
 	}
-	return err
+	c.cli.LogDryRun(ctx, "update", updated)
+	if !issuerChanged {
+		return nil
+	}
+	c.reissues.Forget(key)
+	if !c.capabilities.Reissue {
+		c.logger.WarnContext(ctx, "Issuer has changed, reissue disabled by missing permissions", "key", key, "feature", FeatureReissue)
+		return nil
+	}
+	c.logger.InfoContext(ctx, "Issuer has changed, requesting reissue", "key", key, "from", from.Name, "to", desired.Spec.IssuerRef.Name)
+	message := fmt.Sprintf("Issuer changed from %s %q to %s %q", from.Kind, from.Name, desired.Spec.IssuerRef.Kind, desired.Spec.IssuerRef.Name)
+	// the spec hash now matches, so the reissue is remembered until requested
+	c.pendingReissues.Set(key, message)
+	return c.retryPendingReissue(ctx, key, updated)
+}
+
+// retryPendingReissue requests the reissue recorded for key, if any, when a
+// previous attempt failed after the spec was updated.
+func (c *SkupperCertificateInformer) retryPendingReissue(ctx context.Context, key string, current *cm.Certificate) error {
+	message, ok := c.pendingReissues.Get(key)
+	if !ok {
+		return nil
+	}
+	if err := RequestReissue(ctx, c.cli, current, "IssuerChanged", message); err != nil {
+		return err
+	}
+	c.pendingReissues.Delete(key)
+	return nil
+}
+
+func (c *SkupperCertificateInformer) isHandled(key string, obj *v2alpha1.Certificate) bool {
+	hash, ok := c.hashes.Get(key)
+	return ok && hash == certmgr.SpecHash(obj)
+}
+
+func (c *SkupperCertificateInformer) handled(key string, obj *v2alpha1.Certificate) {
+	c.certificates.Set(key, obj)
+	c.hashes.Set(key, certmgr.SpecHash(obj))
+}
+
+// isCAHandled compares the spec hash of the CA certificate last handled,
+// which covers its name constraints, with the one of caCert.
+func (c *SkupperCertificateInformer) isCAHandled(key string, caCert *cm.Certificate) bool {
+	hash, ok := c.caHashes.Get(key)
+	return ok && hash == certmgr.SpecHashOf(caCert)
+}
+
+func (c *SkupperCertificateInformer) caHandled(key string, obj *v2alpha1.Certificate, caCert *cm.Certificate) {
+	c.handled(key, obj)
+	c.caHashes.Set(key, certmgr.SpecHashOf(caCert))
+}
+
+// signedHosts returns the hosts of the delegated certificates signed by the
+// given CA.
+func (c *SkupperCertificateInformer) signedHosts(ca *v2alpha1.Certificate) []string {
+	var hosts []string
+	for _, item := range c.informer.GetStore().List() {
+		cert := item.(*v2alpha1.Certificate)
+		if cert.Namespace == ca.Namespace && cert.Spec.Ca == ca.Name && certmgr.IsManaged(cert) {
+			hosts = append(hosts, cert.Spec.Hosts...)
+		}
+	}
+	return hosts
 }
 
 func (c *SkupperCertificateInformer) needsRootIssuer(namespace string) bool {
@@ -217,26 +397,21 @@ func (c *SkupperCertificateInformer) needsRootIssuer(namespace string) bool {
 	return rootIssuer == ""
 }
 
-func (c *SkupperCertificateInformer) ensureNoIssuerFor(obj *v2alpha1.Certificate) error {
+func (c *SkupperCertificateInformer) ensureNoIssuerFor(ctx context.Context, obj *v2alpha1.Certificate) error {
 	issuersCli := c.cli.CertManager.CertmanagerV1().Issuers(obj.Namespace)
-	issuer, err := issuersCli.Get(context.Background(), obj.Name, v1.GetOptions{})
+	issuer, err := issuersCli.Get(ctx, obj.Name, v1.GetOptions{})
 	if err == nil {
 		if client.IsOwnedBy(issuer, obj, v2alpha1.SchemeGroupVersion.WithKind("Certificate")) {
-			c.logger.Info("Removing issuer no longer needed", "target-namespace", obj.Namespace, "target-name", obj.Name)
-			err = issuersCli.Delete(context.Background(), obj.Name, v1.DeleteOptions{})
-			return err
+			if !c.capabilities.IssuerCleanup {
+				c.logger.WarnContext(ctx, "Issuer no longer needed, removal disabled by missing permissions", "target-namespace", obj.Namespace, "target-name", obj.Name, "feature", FeatureIssuerCleanup)
+				return nil
+			}
+			c.logger.InfoContext(ctx, "Removing issuer no longer needed", "target-namespace", obj.Namespace, "target-name", obj.Name)
+			if err = issuersCli.Delete(ctx, obj.Name, c.cli.DeleteOptions()); err != nil {
+				return err
+			}
+			c.cli.LogDryRun(ctx, "delete", issuer)
 		}
 	}
 	return nil
 }
-
-func SkupperCertificateReadyOrPending(cli *client.Client, obj *v2alpha1.Certificate, ready bool, message string) error {
-	condition := v2alpha1.ReadyCondition()
-	if !ready {
-		condition = v2alpha1.PendingCondition(message)
-	}
-	certsCli := cli.Skupper.SkupperV2alpha1().Certificates(obj.Namespace)
-	obj.Status.SetCondition(v2alpha1.CONDITION_TYPE_READY, condition, obj.Generation)
-	_, err := certsCli.UpdateStatus(context.Background(), obj, v1.UpdateOptions{})
-	return err
-}
@@ -0,0 +1,58 @@
+package informer
+
+import (
+	"context"
+
+	"skupper-cert-manager/internal/kube/client"
+
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// StatusMutator changes the given status in place and reports whether
+// anything has actually changed.
+type StatusMutator func(status *v2alpha1.CertificateStatus, generation int64) bool
+
+func SkupperCertificateReadyOrPending(ctx context.Context, cli *client.Client, obj *v2alpha1.Certificate, ready bool, message string) error {
+	condition := v2alpha1.ReadyCondition()
+	if !ready {
+		condition = v2alpha1.PendingCondition(message)
+	}
+	return UpdateSkupperCertificateStatus(ctx, cli, obj, func(status *v2alpha1.CertificateStatus, generation int64) bool {
+		return status.SetCondition(v2alpha1.CONDITION_TYPE_READY, condition, generation)
+	})
+}
+
+// UpdateSkupperCertificateStatus applies mutate to a copy of obj and writes
+// the resulting status. On conflicts the latest version is read back from the
+// API server and mutate is applied again. The write is skipped when mutate
+// reports no change. The given obj is never modified, so it is safe to pass
+// objects owned by an informer store.
+func UpdateSkupperCertificateStatus(ctx context.Context, cli *client.Client, obj *v2alpha1.Certificate, mutate StatusMutator) error {
+	certsCli := cli.Skupper.SkupperV2alpha1().Certificates(obj.Namespace)
+	current := obj.DeepCopy()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if current == nil {
+			latest, err := certsCli.Get(ctx, obj.Name, v1.GetOptions{})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			current = latest
+		}
+		if !mutate(&current.Status, current.Generation) {
+			return nil
+		}
+		_, err := certsCli.UpdateStatus(ctx, current, cli.UpdateOptions())
+		if err != nil {
+			current = nil
+			return err
+		}
+		cli.LogDryRun(ctx, "update status", current)
+		return nil
+	})
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+type attrsKey struct{}
+
+// WithAttrs returns a context carrying attributes that are added to every
+// record logged using it, such as the id of the reconcile being processed.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	current, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey{}, append(slices.Clone(current), attrs...))
+}
+
+// contextHandler adds the attributes carried by the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
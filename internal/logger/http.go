@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LevelHandler serves the current log levels on GET and changes them on PUT
+// or POST, using the request body or the "level" query parameter, for
+// example: curl -X PUT localhost:8081/loglevel -d 'info,informer.skupper=debug'
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			spec := r.URL.Query().Get("level")
+			if spec == "" {
+				body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				spec = strings.TrimSpace(string(body))
+			}
+			if err := SetLevels(spec); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		_, _ = fmt.Fprintln(w, Levels())
+	})
+}
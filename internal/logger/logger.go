@@ -1,16 +1,145 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
 )
 
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config holds the logging settings. Level is a comma separated list holding
+// the default level and optional per component overrides, for example:
+// "info,informer.skupper=debug".
+type Config struct {
+	Level     string
+	Format    string
+	AddSource bool
+}
+
+type levels struct {
+	dflt       slog.Level
+	components map[string]slog.Level
+}
+
+var (
+	mutex   sync.RWMutex
+	config  = Config{Level: "info", Format: FormatJSON}
+	current = levels{dflt: slog.LevelInfo}
+)
+
+// Configure applies the given configuration. The format and source settings
+// only affect loggers created afterwards, while levels are applied to all
+// loggers.
+func Configure(cfg Config) error {
+	parsed, err := parseLevels(cfg.Level)
+	if err != nil {
+		return err
+	}
+	if cfg.Format != FormatJSON && cfg.Format != FormatText {
+		return fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	config = cfg
+	current = parsed
+	return nil
+}
+
+// SetLevels changes the levels of all loggers at runtime.
+func SetLevels(spec string) error {
+	parsed, err := parseLevels(spec)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = parsed
+	return nil
+}
+
+// ResetLevels restores the levels given through Configure.
+func ResetLevels() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current, _ = parseLevels(config.Level)
+}
+
+// Levels returns the levels currently in use, in the same format accepted by
+// SetLevels.
+func Levels() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	spec := []string{strings.ToLower(current.dflt.String())}
+	for component, level := range current.components {
+		spec = append(spec, component+"="+strings.ToLower(level.String()))
+	}
+	return strings.Join(spec, ",")
+}
+
+// ValidateLevels verifies that spec is a valid list of levels.
+func ValidateLevels(spec string) error {
+	_, err := parseLevels(spec)
+	return err
+}
+
+func parseLevels(spec string) (levels, error) {
+	res := levels{
+		dflt:       slog.LevelInfo,
+		components: map[string]slog.Level{},
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		component, value, found := strings.Cut(item, "=")
+		if !found {
+			value = component
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+			return res, fmt.Errorf("invalid log level %q: %w", item, err)
+		}
+		if found {
+			res.components[strings.TrimSpace(component)] = level
+		} else {
+			res.dflt = level
+		}
+	}
+	return res, nil
+}
+
+// componentLevel resolves the level of a component every time it is used,
+// so that level changes are applied to existing loggers.
+type componentLevel string
+
+func (c componentLevel) Level() slog.Level {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if level, ok := current.components[string(c)]; ok {
+		return level
+	}
+	return current.dflt
+}
+
 func NewLogger(component, namespace string) *slog.Logger {
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		AddSource: false,
-		Level:     slog.LevelInfo,
-	})
-	return slog.New(handler).With(
+	mutex.RLock()
+	opts := &slog.HandlerOptions{
+		AddSource: config.AddSource,
+		Level:     componentLevel(component),
+	}
+	var handler slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if config.Format == FormatText {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+	mutex.RUnlock()
+	return slog.New(contextHandler{handler}).With(
 		"component", component,
 		"namespace", namespace)
 }
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		spec       string
+		dflt       slog.Level
+		components map[string]slog.Level
+		err        bool
+	}{
+		{spec: "", dflt: slog.LevelInfo},
+		{spec: "debug", dflt: slog.LevelDebug},
+		{spec: "warn,informer.skupper=debug", dflt: slog.LevelWarn, components: map[string]slog.Level{"informer.skupper": slog.LevelDebug}},
+		{spec: " informer.config = error , ", dflt: slog.LevelInfo, components: map[string]slog.Level{"informer.config": slog.LevelError}},
+		{spec: "verbose", err: true},
+		{spec: "info,client=loud", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseLevels(tt.spec)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.dflt != tt.dflt {
+				t.Errorf("default level %v, expected %v", got.dflt, tt.dflt)
+			}
+			if len(got.components) != len(tt.components) {
+				t.Fatalf("components %v, expected %v", got.components, tt.components)
+			}
+			for component, level := range tt.components {
+				if got.components[component] != level {
+					t.Errorf("%s level %v, expected %v", component, got.components[component], level)
+				}
+			}
+		})
+	}
+}
+
+func TestSetAndResetLevels(t *testing.T) {
+	if err := Configure(Config{Level: "warn", Format: FormatJSON}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Configure(Config{Level: "info", Format: FormatJSON}) })
+	level := componentLevel("client")
+	if err := SetLevels("info,client=debug"); err != nil {
+		t.Fatal(err)
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("component level %v after SetLevels", level.Level())
+	}
+	if err := SetLevels("loud"); err == nil {
+		t.Error("invalid levels accepted")
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Error("invalid levels changed the current levels")
+	}
+	ResetLevels()
+	if level.Level() != slog.LevelWarn {
+		t.Errorf("component level %v after ResetLevels, expected the configured warn", level.Level())
+	}
+}
@@ -0,0 +1,381 @@
+package manifests
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/informer"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	DefaultName      = "skupper-cert-manager"
+	DefaultNamespace = "skupper"
+	DefaultImage     = "quay.io/fgiorgetti/skupper-cert-manager"
+	DefaultHTTPPort  = 8080
+
+	envPrefix = "SKUPPER_CERT_MANAGER_"
+)
+
+// Options defines the scope and features the manifests are rendered for.
+type Options struct {
+	Name              string
+	Namespace         string
+	Image             string
+	HTTPPort          int
+	Namespaces        []string
+	NamespaceSelector string
+	// Features lists the optional features granted permissions.
+	Features []string
+}
+
+// OptionalFeatures returns the features that can be left out of the RBAC
+// rules, the controller disabling them at runtime.
+func OptionalFeatures() []string {
+	var features []string
+	for _, rule := range allRules() {
+		if rule.Optional && !slices.Contains(features, rule.Feature) {
+			features = append(features, rule.Feature)
+		}
+	}
+	return features
+}
+
+// DefaultFeatures returns the optional features granted by default, leaving
+// out the cluster features, which need cluster wide permissions.
+func DefaultFeatures() []string {
+	return slices.DeleteFunc(OptionalFeatures(), func(feature string) bool {
+		return slices.Contains(informer.ClusterFeatures(), feature)
+	})
+}
+
+func allRules() []informer.Rule {
+	return slices.Concat(informer.CertificateRules, informer.ConfigRules, informer.SharedRootRules, informer.NamespaceSelectorRules, informer.ClusterRules)
+}
+
+// Manifest is a rendered object, along with the file it belongs to when
+// written to a directory.
+type Manifest struct {
+	File   string
+	Object runtime.Object
+}
+
+func (o Options) Validate() error {
+	if len(o.Namespaces) > 0 && o.NamespaceSelector != "" {
+		return fmt.Errorf("namespaces and namespace-selector are mutually exclusive")
+	}
+	optional := OptionalFeatures()
+	for _, feature := range o.Features {
+		if !slices.Contains(optional, feature) {
+			return fmt.Errorf("unknown feature %q, valid features are: %s", feature, strings.Join(optional, ", "))
+		}
+	}
+	return nil
+}
+
+// Render returns the manifests needed to run the controller.
+func Render(o Options) []Manifest {
+	manifests := []Manifest{
+		{File: "serviceaccount.yaml", Object: o.serviceAccount()},
+	}
+	manifests = append(manifests, o.rbac()...)
+	manifests = append(manifests,
+		Manifest{File: "configmap.yaml", Object: o.configMap()},
+		Manifest{File: "deployment.yaml", Object: o.deployment()},
+	)
+	return manifests
+}
+
+// Marshal renders the given manifests as a multi document YAML stream.
+func Marshal(manifests []Manifest) ([]byte, error) {
+	var out []byte
+	for i, m := range manifests {
+		data, err := yaml.Marshal(m.Object)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			out = append(out, []byte("---\n")...)
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+// Kustomization returns a kustomization.yaml listing the files of the given
+// manifests.
+func Kustomization(manifests []Manifest) ([]byte, error) {
+	var resources []string
+	for _, m := range manifests {
+		if !slices.Contains(resources, m.File) {
+			resources = append(resources, m.File)
+		}
+	}
+	return yaml.Marshal(map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+}
+
+func (o Options) labels() map[string]string {
+	return map[string]string{
+		"app": o.Name,
+	}
+}
+
+func (o Options) objectMeta(namespace string) v1.ObjectMeta {
+	return v1.ObjectMeta{
+		Name:      o.Name,
+		Namespace: namespace,
+		Labels:    o.labels(),
+	}
+}
+
+func (o Options) serviceAccount() *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   v1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+	}
+}
+
+// enabled returns the given rules, leaving out the optional features not
+// enabled.
+func (o Options) enabled(rules []informer.Rule) []informer.Rule {
+	return slices.DeleteFunc(slices.Clone(rules), func(rule informer.Rule) bool {
+		return rule.Optional && !slices.Contains(o.Features, rule.Feature)
+	})
+}
+
+// rbac returns the roles and bindings for the scope mode. Certificates are
+// watched cluster wide unless a list of namespaces is given, as namespaces
+// matching a selector are only known at runtime. Cluster scoped resources
+// always need a ClusterRole.
+func (o Options) rbac() []Manifest {
+	var manifests []Manifest
+	namespaced := map[string][]informer.Rule{
+		// the shared root is issued in the controller namespace by default
+		o.Namespace: slices.Concat(o.enabled(informer.ConfigRules), o.enabled(informer.SharedRootRules)),
+	}
+	namespaces := []string{o.Namespace}
+	switch {
+	case len(o.Namespaces) > 0:
+		for _, namespace := range o.Namespaces {
+			if !slices.Contains(namespaces, namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+			namespaced[namespace] = append(namespaced[namespace], o.enabled(informer.CertificateRules)...)
+		}
+		if rules := o.enabled(informer.ClusterRules); len(rules) > 0 {
+			manifests = append(manifests, o.clusterRole(rules)...)
+		}
+	case o.NamespaceSelector != "":
+		manifests = append(manifests, o.clusterRole(slices.Concat(o.enabled(informer.NamespaceSelectorRules), o.enabled(informer.CertificateRules), o.enabled(informer.ClusterRules)))...)
+	default:
+		manifests = append(manifests, o.clusterRole(slices.Concat(o.enabled(informer.CertificateRules), o.enabled(informer.ClusterRules)))...)
+	}
+	for _, namespace := range namespaces {
+		manifests = append(manifests, o.role(namespace, namespaced[namespace])...)
+	}
+	return manifests
+}
+
+func (o Options) clusterRole(rules []informer.Rule) []Manifest {
+	role := &rbacv1.ClusterRole{
+		TypeMeta: v1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: v1.ObjectMeta{
+			Name:   o.Name,
+			Labels: o.labels(),
+		},
+		Rules: policyRules(rules),
+	}
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   v1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: role.ObjectMeta,
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: o.Name},
+		Subjects:   o.subjects(),
+	}
+	return []Manifest{{File: "rbac.yaml", Object: role}, {File: "rbac.yaml", Object: binding}}
+}
+
+func (o Options) role(namespace string, rules []informer.Rule) []Manifest {
+	role := &rbacv1.Role{
+		TypeMeta:   v1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+		ObjectMeta: o.objectMeta(namespace),
+		Rules:      policyRules(rules),
+	}
+	binding := &rbacv1.RoleBinding{
+		TypeMeta:   v1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+		ObjectMeta: o.objectMeta(namespace),
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: o.Name},
+		Subjects:   o.subjects(),
+	}
+	return []Manifest{{File: "rbac.yaml", Object: role}, {File: "rbac.yaml", Object: binding}}
+}
+
+func (o Options) subjects() []rbacv1.Subject {
+	return []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      o.Name,
+		Namespace: o.Namespace,
+	}}
+}
+
+// policyRules merges the verbs of rules targeting the same resource.
+func policyRules(rules []informer.Rule) []rbacv1.PolicyRule {
+	var policies []rbacv1.PolicyRule
+	index := map[string]int{}
+	for _, rule := range rules {
+		key := rule.Group + "/" + rule.Resource
+		i, ok := index[key]
+		if !ok {
+			i = len(policies)
+			index[key] = i
+			policies = append(policies, rbacv1.PolicyRule{
+				APIGroups: []string{rule.Group},
+				Resources: []string{rule.Resource},
+			})
+		}
+		for _, verb := range rule.Verbs {
+			if !slices.Contains(policies[i].Verbs, verb) {
+				policies[i].Verbs = append(policies[i].Verbs, verb)
+			}
+		}
+	}
+	return policies
+}
+
+const defaultConfig = `# rootIssuer: /my-cluster-issuer
+# issuer: my-issuer
+# issuerMap:
+#   skupper-site-ca: custom-issuer
+# adoption:
+#   enabled: true
+#   renewBefore: 720h
+# caRotation:
+#   enabled: true
+#   gracePeriod: 168h
+#   publishDelay: 5m
+# trustBundles:
+#   enabled: true
+#   scope: ca
+#   key: ca.crt
+#   targetNamespaces:
+#     matchLabels:
+#       skupper.io/trust: "true"
+# nameConstraints:
+#   enabled: true
+#   permittedDNSDomains:
+#   - example.com
+# nameConstraintsMap:
+#   skupper-site-ca:
+#     enabled: true
+# chain:
+#   intermediates:
+#   - name: skupper-organization-ca
+#     subject: Organization CA
+# sharedRoot:
+#   enabled: true
+#   name: skupper-root
+# approverPolicy:
+#   enabled: true
+#   serviceAccount:
+#     name: cert-manager
+#     namespace: cert-manager
+# namespaces:
+#   my-namespace:
+#     issuer: my-namespace-issuer
+`
+
+func (o Options) configMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   v1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+		Data: map[string]string{
+			certmgr.ConfigKey: defaultConfig,
+		},
+	}
+}
+
+func (o Options) env() []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name: "POD_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		},
+		{Name: envPrefix + "CLIENT_MODE", Value: "in-cluster"},
+		{Name: envPrefix + "CONFIG_MAP", Value: o.Name},
+		{Name: envPrefix + "HTTP_ADDRESS", Value: fmt.Sprintf(":%d", o.HTTPPort)},
+	}
+	var clusterFeatures []string
+	for _, feature := range o.Features {
+		if slices.Contains(informer.ClusterFeatures(), feature) {
+			clusterFeatures = append(clusterFeatures, feature)
+		}
+	}
+	if len(clusterFeatures) > 0 {
+		env = append(env, corev1.EnvVar{Name: envPrefix + "CLUSTER_FEATURES", Value: strings.Join(clusterFeatures, ",")})
+	}
+	switch {
+	case len(o.Namespaces) > 0:
+		env = append(env, corev1.EnvVar{Name: envPrefix + "NAMESPACES", Value: strings.Join(o.Namespaces, ",")})
+	case o.NamespaceSelector != "":
+		env = append(env, corev1.EnvVar{Name: envPrefix + "NAMESPACE_SELECTOR", Value: o.NamespaceSelector})
+	}
+	return env
+}
+
+func (o Options) probe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromString("http"),
+			},
+		},
+		PeriodSeconds: 10,
+	}
+}
+
+func (o Options) deployment() *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		TypeMeta:   v1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &v1.LabelSelector{MatchLabels: o.labels()},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{
+					Labels: o.labels(),
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: o.Name,
+					Containers: []corev1.Container{{
+						Name:  o.Name,
+						Image: o.Image,
+						Env:   o.env(),
+						Ports: []corev1.ContainerPort{{
+							Name:          "http",
+							ContainerPort: int32(o.HTTPPort),
+							Protocol:      corev1.ProtocolTCP,
+						}},
+						LivenessProbe:  o.probe("/healthz"),
+						ReadinessProbe: o.probe("/readyz"),
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,235 @@
+package manifests
+
+import (
+	"slices"
+	"testing"
+
+	"skupper-cert-manager/internal/kube/informer"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestPolicyRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []informer.Rule
+		expected []rbacv1.PolicyRule
+	}{
+		{
+			name: "verbs of the same resource merged",
+			rules: []informer.Rule{
+				{Resource: "secrets", Verbs: []string{"get"}},
+				{Resource: "secrets", Verbs: []string{"get", "update"}},
+			},
+			expected: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "update"}},
+			},
+		},
+		{
+			name: "groups kept apart",
+			rules: []informer.Rule{
+				{Group: "cert-manager.io", Resource: "certificates", Verbs: []string{"get"}},
+				{Group: "skupper.io", Resource: "certificates", Verbs: []string{"get"}},
+			},
+			expected: []rbacv1.PolicyRule{
+				{APIGroups: []string{"cert-manager.io"}, Resources: []string{"certificates"}, Verbs: []string{"get"}},
+				{APIGroups: []string{"skupper.io"}, Resources: []string{"certificates"}, Verbs: []string{"get"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policyRules(tt.rules)
+			equal := slices.EqualFunc(got, tt.expected, func(a, b rbacv1.PolicyRule) bool {
+				return slices.Equal(a.APIGroups, b.APIGroups) && slices.Equal(a.Resources, b.Resources) && slices.Equal(a.Verbs, b.Verbs)
+			})
+			if !equal {
+				t.Errorf("rules %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// kinds returns the kinds of the rendered RBAC objects by namespace, the
+// cluster scoped ones under "".
+func kinds(manifests []Manifest) map[string][]string {
+	res := map[string][]string{}
+	for _, m := range manifests {
+		switch obj := m.Object.(type) {
+		case *rbacv1.ClusterRole:
+			res[""] = append(res[""], "ClusterRole")
+		case *rbacv1.ClusterRoleBinding:
+			res[""] = append(res[""], "ClusterRoleBinding")
+		case *rbacv1.Role:
+			res[obj.Namespace] = append(res[obj.Namespace], "Role")
+		case *rbacv1.RoleBinding:
+			res[obj.Namespace] = append(res[obj.Namespace], "RoleBinding")
+		}
+	}
+	return res
+}
+
+func TestRenderScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  Options
+		expected map[string][]string
+	}{
+		{
+			name:    "cluster wide",
+			options: Options{Name: DefaultName, Namespace: "skupper"},
+			expected: map[string][]string{
+				"":        {"ClusterRole", "ClusterRoleBinding"},
+				"skupper": {"Role", "RoleBinding"},
+			},
+		},
+		{
+			name:    "namespaces",
+			options: Options{Name: DefaultName, Namespace: "skupper", Namespaces: []string{"ns1", "skupper"}},
+			expected: map[string][]string{
+				"skupper": {"Role", "RoleBinding"},
+				"ns1":     {"Role", "RoleBinding"},
+			},
+		},
+		{
+			name:    "namespaces with default features",
+			options: Options{Name: DefaultName, Namespace: "skupper", Namespaces: []string{"ns1"}, Features: DefaultFeatures()},
+			expected: map[string][]string{
+				"skupper": {"Role", "RoleBinding"},
+				"ns1":     {"Role", "RoleBinding"},
+			},
+		},
+		{
+			name:    "namespaces with cluster features",
+			options: Options{Name: DefaultName, Namespace: "skupper", Namespaces: []string{"ns1"}, Features: []string{informer.FeatureTrustBundles}},
+			expected: map[string][]string{
+				"":        {"ClusterRole", "ClusterRoleBinding"},
+				"skupper": {"Role", "RoleBinding"},
+				"ns1":     {"Role", "RoleBinding"},
+			},
+		},
+		{
+			name:    "namespace selector",
+			options: Options{Name: DefaultName, Namespace: "skupper", NamespaceSelector: "skupper=true"},
+			expected: map[string][]string{
+				"":        {"ClusterRole", "ClusterRoleBinding"},
+				"skupper": {"Role", "RoleBinding"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kinds(Render(tt.options))
+			if len(got) != len(tt.expected) {
+				t.Fatalf("rendered %v, expected %v", got, tt.expected)
+			}
+			for namespace, expected := range tt.expected {
+				if !slices.Equal(got[namespace], expected) {
+					t.Errorf("namespace %q: rendered %v, expected %v", namespace, got[namespace], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderFeatures(t *testing.T) {
+	// granted tells whether the rendered ClusterRole grants the verb on the
+	// resource of the group
+	granted := func(manifests []Manifest, group, resource, verb string) bool {
+		for _, m := range manifests {
+			if role, ok := m.Object.(*rbacv1.ClusterRole); ok {
+				for _, rule := range role.Rules {
+					if slices.Contains(rule.APIGroups, group) && slices.Contains(rule.Resources, resource) && slices.Contains(rule.Verbs, verb) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	tests := []struct {
+		name     string
+		features []string
+		resource string
+		verb     string
+		expected bool
+	}{
+		{name: "issuer cleanup not enabled", resource: "issuers", verb: "delete"},
+		{name: "issuer cleanup", features: []string{informer.FeatureIssuerCleanup}, resource: "issuers", verb: "delete", expected: true},
+		{name: "reissue not enabled", features: []string{informer.FeatureIssuerCleanup}, resource: "certificates/status", verb: "update"},
+		{name: "reissue", features: []string{informer.FeatureReissue}, resource: "certificates/status", verb: "update", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests := Render(Options{Name: DefaultName, Namespace: "skupper", Features: tt.features})
+			if got := granted(manifests, "cert-manager.io", tt.resource, tt.verb); got != tt.expected {
+				t.Errorf("%s %s granted = %v, expected %v", tt.verb, tt.resource, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		valid   bool
+	}{
+		{name: "namespaces", options: Options{Namespaces: []string{"ns1"}, Features: []string{informer.FeatureReissue}}, valid: true},
+		{name: "namespaces and selector", options: Options{Namespaces: []string{"ns1"}, NamespaceSelector: "skupper=true"}},
+		{name: "unknown feature", options: Options{Features: []string{"unknown"}}},
+		{name: "required feature", options: Options{Features: []string{informer.FeatureWatch}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate(); (err == nil) != tt.valid {
+				t.Errorf("validation error %v, expected valid = %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDefaultFeatures(t *testing.T) {
+	tests := []struct {
+		feature string
+		granted bool
+	}{
+		{feature: informer.FeatureAdoption, granted: true},
+		{feature: informer.FeatureReissue, granted: true},
+		{feature: informer.FeatureTrustBundles},
+		{feature: informer.FeatureApproverPolicy},
+		{feature: informer.FeatureSharedRoot},
+	}
+	defaults := DefaultFeatures()
+	for _, tt := range tests {
+		t.Run(tt.feature, func(t *testing.T) {
+			if granted := slices.Contains(defaults, tt.feature); granted != tt.granted {
+				t.Errorf("granted by default = %v, expected %v", granted, tt.granted)
+			}
+		})
+	}
+}
+
+func TestClusterFeaturesEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		features []string
+		expected string
+	}{
+		{name: "default features", features: DefaultFeatures()},
+		{name: "cluster features", features: []string{informer.FeatureAdoption, informer.FeatureSharedRoot, informer.FeatureTrustBundles}, expected: informer.FeatureSharedRoot + "," + informer.FeatureTrustBundles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, e := range (Options{Features: tt.features}).env() {
+				if e.Name == envPrefix+"CLUSTER_FEATURES" {
+					got = e.Value
+				}
+			}
+			if got != tt.expected {
+				t.Errorf("cluster features %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package migrate
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// BackupLabel is set on backup Secrets, holding the name of the Secret
+	// they are a copy of.
+	BackupLabel  = "skupper.io/cert-manager-backup-of"
+	backupSuffix = "-cert-manager-backup"
+
+	DefaultReadyTimeout = 5 * time.Minute
+	pollInterval        = 2 * time.Second
+)
+
+// Migrator delegates the Skupper Certificates of a namespace to cert-manager
+// and rolls them back.
+type Migrator struct {
+	cli          *client.Client
+	readyTimeout time.Duration
+	logger       *slog.Logger
+}
+
+func NewMigrator(cli *client.Client, readyTimeout time.Duration, logger *slog.Logger) *Migrator {
+	return &Migrator{
+		cli:          cli,
+		readyTimeout: readyTimeout,
+		logger:       logger,
+	}
+}
+
+// ordered returns the certificates with CAs first, so that the issuers of
+// the other certificates exist by the time they are delegated.
+func ordered(certs []v2alpha1.Certificate) []*v2alpha1.Certificate {
+	var res []*v2alpha1.Certificate
+	for i := range certs {
+		res = append(res, &certs[i])
+	}
+	slices.SortStableFunc(res, func(a, b *v2alpha1.Certificate) int {
+		switch {
+		case a.Spec.Signing == b.Spec.Signing:
+			return 0
+		case a.Spec.Signing:
+			return -1
+		}
+		return 1
+	})
+	return res
+}
+
+// Migrate backs up the Secrets of the certificates not delegated yet and
+// delegates them one at a time, waiting for each to become ready and
+// verifying its Secret. It stops at the first failure.
+func (m *Migrator) Migrate(ctx context.Context, namespace string) error {
+	list, err := m.cli.Skupper.SkupperV2alpha1().Certificates(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list Skupper certificates: %w", err)
+	}
+	certs := slices.DeleteFunc(ordered(list.Items), certmgr.IsManaged)
+	if len(certs) == 0 {
+		m.logger.InfoContext(ctx, "No certificates to migrate", "target-namespace", namespace)
+		return nil
+	}
+	for _, cert := range certs {
+		if err = m.backup(ctx, cert); err != nil {
+			return fmt.Errorf("unable to back up Secret of %s: %w", cert.Key(), err)
+		}
+	}
+	for _, cert := range certs {
+		m.logger.InfoContext(ctx, "Delegating certificate", "key", cert.Key(), "signing", cert.Spec.Signing)
+		if err = m.setManaged(ctx, cert, true); err != nil {
+			return fmt.Errorf("unable to delegate %s: %w", cert.Key(), err)
+		}
+		if err = m.waitReady(ctx, cert); err != nil {
+			return fmt.Errorf("%s not ready, use rollback to restore the original Secrets: %w", cert.Key(), err)
+		}
+		if err = m.verifySecret(ctx, cert); err != nil {
+			return fmt.Errorf("invalid Secret for %s, use rollback to restore the original Secrets: %w", cert.Key(), err)
+		}
+		m.logger.InfoContext(ctx, "Certificate migrated", "key", cert.Key())
+	}
+	return nil
+}
+
+// Rollback stops delegating the certificates of the namespace, removes the
+// cert-manager resources generated for them and restores the backed up
+// Secrets. Certificates are rolled back in the reverse order of migration.
+func (m *Migrator) Rollback(ctx context.Context, namespace string) error {
+	list, err := m.cli.Skupper.SkupperV2alpha1().Certificates(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list Skupper certificates: %w", err)
+	}
+	certs := slices.DeleteFunc(ordered(list.Items), func(cert *v2alpha1.Certificate) bool {
+		return !certmgr.IsManaged(cert)
+	})
+	slices.Reverse(certs)
+	for _, cert := range certs {
+		m.logger.InfoContext(ctx, "Rolling back certificate", "key", cert.Key())
+		if err = m.setManaged(ctx, cert, false); err != nil {
+			return fmt.Errorf("unable to stop delegating %s: %w", cert.Key(), err)
+		}
+		if err = m.removeGenerated(ctx, cert); err != nil {
+			return fmt.Errorf("unable to remove cert-manager resources of %s: %w", cert.Key(), err)
+		}
+		if err = m.restore(ctx, cert); err != nil {
+			return fmt.Errorf("unable to restore Secret of %s: %w", cert.Key(), err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) setManaged(ctx context.Context, cert *v2alpha1.Certificate, managed bool) error {
+	certsCli := m.cli.Skupper.SkupperV2alpha1().Certificates(cert.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := certsCli.Get(ctx, cert.Name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if managed {
+			if current.Spec.Settings == nil {
+				current.Spec.Settings = map[string]string{}
+			}
+			current.Spec.Settings[certmgr.ControllerKey] = certmgr.ControllerName
+		} else {
+			delete(current.Spec.Settings, certmgr.ControllerKey)
+		}
+		updated, err := certsCli.Update(ctx, current, m.cli.UpdateOptions())
+		if err != nil {
+			return err
+		}
+		m.cli.LogDryRun(ctx, "update", updated)
+		cert.Generation = updated.Generation
+		return nil
+	})
+}
+
+func (m *Migrator) waitReady(ctx context.Context, cert *v2alpha1.Certificate) error {
+	if m.cli.DryRun {
+		return nil
+	}
+	certsCli := m.cli.Skupper.SkupperV2alpha1().Certificates(cert.Namespace)
+	return wait.PollUntilContextTimeout(ctx, pollInterval, m.readyTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := certsCli.Get(ctx, cert.Name, v1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		ready := meta.FindStatusCondition(current.Status.Conditions, v2alpha1.CONDITION_TYPE_READY)
+		return ready != nil && ready.Status == v1.ConditionTrue && ready.ObservedGeneration >= cert.Generation, nil
+	})
+}
+
+// verifySecret checks the Secret of the given certificate, see verifyKeyPair.
+func (m *Migrator) verifySecret(ctx context.Context, cert *v2alpha1.Certificate) error {
+	if m.cli.DryRun {
+		return nil
+	}
+	secret, err := m.cli.Kube.CoreV1().Secrets(cert.Namespace).Get(ctx, cert.Name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return verifyKeyPair(secret, cert.Spec.Signing)
+}
+
+// verifyKeyPair checks that the Secret holds a valid key pair, signed by the
+// CA it holds when there is one and it is not a CA itself.
+func verifyKeyPair(secret *corev1.Secret, signing bool) error {
+	pair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate is only valid from %s to %s", leaf.NotBefore, leaf.NotAfter)
+	}
+	caData := secret.Data[cmmeta.TLSCAKey]
+	if len(caData) == 0 || signing {
+		return nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caData) {
+		return fmt.Errorf("invalid %s", cmmeta.TLSCAKey)
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range pair.Certificate[1:] {
+		if c, err := x509.ParseCertificate(der); err == nil {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+func backupName(name string) string {
+	return name + backupSuffix
+}
+
+// backup copies the Secret of the given certificate, keeping any existing
+// backup as it holds the original Secret.
+func (m *Migrator) backup(ctx context.Context, cert *v2alpha1.Certificate) error {
+	secretsCli := m.cli.Kube.CoreV1().Secrets(cert.Namespace)
+	secret, err := secretsCli.Get(ctx, cert.Name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := secret.Annotations[cm.CertificateNameKey]; ok {
+		return nil
+	}
+	backup := &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        backupName(cert.Name),
+			Namespace:   cert.Namespace,
+			Labels:      map[string]string{BackupLabel: cert.Name},
+			Annotations: maps.Clone(secret.Annotations),
+		},
+		Type: secret.Type,
+		Data: maps.Clone(secret.Data),
+	}
+	_, err = secretsCli.Create(ctx, backup, m.cli.CreateOptions())
+	if errors.IsAlreadyExists(err) {
+		m.logger.InfoContext(ctx, "Keeping existing backup", "key", cert.Key(), "backup", backup.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.cli.LogDryRun(ctx, "create", backup)
+	m.logger.InfoContext(ctx, "Secret backed up", "key", cert.Key(), "backup", backup.Name)
+	return nil
+}
+
+// restore copies the backup back into the Secret of the given certificate
+// and deletes the backup.
+func (m *Migrator) restore(ctx context.Context, cert *v2alpha1.Certificate) error {
+	secretsCli := m.cli.Kube.CoreV1().Secrets(cert.Namespace)
+	backup, err := secretsCli.Get(ctx, backupName(cert.Name), v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		m.logger.WarnContext(ctx, "No backup found, Secret left as is", "key", cert.Key())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secretsCli.Get(ctx, cert.Name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			secret := &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: cert.Name, Namespace: cert.Namespace, Annotations: backup.Annotations},
+				Type:       backup.Type,
+				Data:       backup.Data,
+			}
+			_, err = secretsCli.Create(ctx, secret, m.cli.CreateOptions())
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		secret.Annotations = backup.Annotations
+		secret.Data = backup.Data
+		_, err = secretsCli.Update(ctx, secret, m.cli.UpdateOptions())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	m.logger.InfoContext(ctx, "Secret restored", "key", cert.Key(), "backup", backup.Name)
+	return secretsCli.Delete(ctx, backup.Name, m.cli.DeleteOptions())
+}
+
+// removeGenerated deletes the cert-manager Certificate and Issuer generated
+// for the given certificate, so that cert-manager stops updating its Secret.
+func (m *Migrator) removeGenerated(ctx context.Context, cert *v2alpha1.Certificate) error {
+	gvk := v2alpha1.SchemeGroupVersion.WithKind("Certificate")
+	certsCli := m.cli.CertManager.CertmanagerV1().Certificates(cert.Namespace)
+	cmCert, err := certsCli.Get(ctx, cert.Name, v1.GetOptions{})
+	if err == nil && client.IsOwnedBy(cmCert, cert, gvk) {
+		err = certsCli.Delete(ctx, cert.Name, m.cli.DeleteOptions())
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	issuersCli := m.cli.CertManager.CertmanagerV1().Issuers(cert.Namespace)
+	issuer, err := issuersCli.Get(ctx, cert.Name, v1.GetOptions{})
+	if err == nil && client.IsOwnedBy(issuer, cert, gvk) {
+		err = issuersCli.Delete(ctx, cert.Name, m.cli.DeleteOptions())
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
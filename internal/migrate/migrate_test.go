@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/skupperproject/skupper/pkg/apis/skupper/v2alpha1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOrdered(t *testing.T) {
+	ca := func(name string) v2alpha1.Certificate {
+		return v2alpha1.Certificate{ObjectMeta: v1.ObjectMeta{Name: name}, Spec: v2alpha1.CertificateSpec{Signing: true}}
+	}
+	leaf := func(name string) v2alpha1.Certificate {
+		return v2alpha1.Certificate{ObjectMeta: v1.ObjectMeta{Name: name}}
+	}
+	tests := []struct {
+		name     string
+		certs    []v2alpha1.Certificate
+		expected []string
+	}{
+		{name: "CAs first", certs: []v2alpha1.Certificate{leaf("server"), ca("ca")}, expected: []string{"ca", "server"}},
+		{name: "order kept within kinds", certs: []v2alpha1.Certificate{leaf("server"), ca("ca"), leaf("client"), ca("other-ca")}, expected: []string{"ca", "other-ca", "server", "client"}},
+		{name: "leaves only", certs: []v2alpha1.Certificate{leaf("server"), leaf("client")}, expected: []string{"server", "client"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, cert := range ordered(tt.certs) {
+				given := false
+				for i := range tt.certs {
+					given = given || cert == &tt.certs[i]
+				}
+				if !given {
+					t.Errorf("%s does not point to the given certificates", cert.Name)
+				}
+				names = append(names, cert.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("ordered %v, expected %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newKeyPair(t *testing.T, name string, ca bool, notAfter time.Time, parent *keyPair) *keyPair {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keyPair{cert: cert, key: key}
+}
+
+func newSecret(t *testing.T, pair *keyPair, ca *keyPair) *corev1.Secret {
+	t.Helper()
+	keyDer, err := x509.MarshalECPrivateKey(pair.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{Data: map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pair.cert.Raw}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}}
+	if ca != nil {
+		secret.Data[cmmeta.TLSCAKey] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	}
+	return secret
+}
+
+func TestVerifyKeyPair(t *testing.T) {
+	validity := time.Now().Add(time.Hour)
+	ca := newKeyPair(t, "ca", true, validity, nil)
+	otherCA := newKeyPair(t, "other-ca", true, validity, nil)
+	leaf := newKeyPair(t, "leaf", false, validity, ca)
+	expired := newKeyPair(t, "expired", false, time.Now().Add(-time.Minute), ca)
+
+	mismatched := newSecret(t, leaf, ca)
+	mismatched.Data[corev1.TLSPrivateKeyKey] = newSecret(t, otherCA, nil).Data[corev1.TLSPrivateKeyKey]
+	invalidCA := newSecret(t, leaf, nil)
+	invalidCA.Data[cmmeta.TLSCAKey] = []byte("invalid")
+
+	tests := []struct {
+		name    string
+		secret  *corev1.Secret
+		signing bool
+		wantErr bool
+	}{
+		{"signed by its CA", newSecret(t, leaf, ca), false, false},
+		{"without CA", newSecret(t, leaf, nil), false, false},
+		{"CA", newSecret(t, ca, ca), true, false},
+		{"signed by another CA", newSecret(t, leaf, otherCA), false, true},
+		{"expired", newSecret(t, expired, ca), false, true},
+		{"mismatched key", mismatched, false, true},
+		{"invalid CA", invalidCA, false, true},
+		{"empty", &corev1.Secret{}, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyKeyPair(test.secret, test.signing)
+			if (err != nil) != test.wantErr {
+				t.Errorf("verifyKeyPair() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package status
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"skupper-cert-manager/internal/certmgr"
+	"skupper-cert-manager/internal/kube/client"
+	"skupper-cert-manager/internal/kube/informer"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Certificate is the status of a Skupper Certificate delegated to
+// cert-manager.
+type Certificate struct {
+	Namespace    string     `json:"namespace"`
+	Name         string     `json:"name"`
+	CA           string     `json:"ca,omitempty"`
+	Signing      bool       `json:"signing,omitempty"`
+	Issuer       string     `json:"issuer"`
+	IssuerKind   string     `json:"issuerKind"`
+	IssuerSource string     `json:"issuerSource"`
+	Ready        string     `json:"ready"`
+	Expiry       *time.Time `json:"expiry,omitempty"`
+	Problems     []string   `json:"problems,omitempty"`
+}
+
+// Orphan is a resource generated by the controller whose Skupper
+// Certificate no longer exists.
+type Orphan struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Problem   string `json:"problem"`
+}
+
+// Issuer is the status of an issuer generated by the controller that is not
+// owned by a Skupper Certificate: the intermediates of a chain and the
+// shared root ClusterIssuer.
+type Issuer struct {
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	// PathLen is the path length of an intermediate CA, along with the
+	// number of CA levels expected below it. cert-manager cannot set it, so
+	// an unconstrained path length is reported but not a problem.
+	PathLen string `json:"pathLen,omitempty"`
+	Problem string `json:"problem,omitempty"`
+}
+
+type Report struct {
+	Certificates []Certificate `json:"certificates"`
+	Issuers      []Issuer      `json:"issuers,omitempty"`
+	Orphans      []Orphan      `json:"orphans,omitempty"`
+}
+
+// Consistent reports whether no problems have been found.
+func (r *Report) Consistent() bool {
+	if len(r.Orphans) > 0 {
+		return false
+	}
+	for _, issuer := range r.Issuers {
+		if issuer.Problem != "" {
+			return false
+		}
+	}
+	for _, cert := range r.Certificates {
+		if len(cert.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Collect reports the status of the delegated certificates in the given
+// namespace, or in all namespaces if empty. The configuration must have been
+// set beforehand, so that issuers are resolved as the controller does.
+func Collect(ctx context.Context, cli *client.Client, namespace string) (*Report, error) {
+	skupperCerts, err := cli.Skupper.SkupperV2alpha1().Certificates(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Skupper certificates: %w", err)
+	}
+	cmCerts, err := cli.CertManager.CertmanagerV1().Certificates(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list cert-manager certificates: %w", err)
+	}
+	issuers, err := cli.CertManager.CertmanagerV1().Issuers(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list cert-manager issuers: %w", err)
+	}
+	certsByName := map[types.NamespacedName]*cm.Certificate{}
+	for i := range cmCerts.Items {
+		cert := &cmCerts.Items[i]
+		certsByName[types.NamespacedName{Namespace: cert.Namespace, Name: cert.Name}] = cert
+	}
+	issuersByName := map[types.NamespacedName]*cm.Issuer{}
+	for i := range issuers.Items {
+		issuer := &issuers.Items[i]
+		issuersByName[types.NamespacedName{Namespace: issuer.Namespace, Name: issuer.Name}] = issuer
+	}
+
+	clusterIssuers := map[string]*cm.ClusterIssuer{}
+	clusterIssuerProblem := func(name string) string {
+		issuer, ok := clusterIssuers[name]
+		if !ok {
+			var err error
+			issuer, err = cli.CertManager.CertmanagerV1().ClusterIssuers().Get(ctx, name, v1.GetOptions{})
+			if errors.IsNotFound(err) {
+				issuer = nil
+			} else if err != nil {
+				return fmt.Sprintf("unable to read ClusterIssuer %s: %v", name, err)
+			}
+			clusterIssuers[name] = issuer
+		}
+		if issuer == nil {
+			return fmt.Sprintf("ClusterIssuer %s not found", name)
+		}
+		if ready, message := informer.GetIssuerReadyMessage(issuer.Status.Conditions); !ready {
+			return fmt.Sprintf("ClusterIssuer %s not ready: %s", name, message)
+		}
+		return ""
+	}
+	issuerProblem := func(namespace, name string) string {
+		issuer, ok := issuersByName[types.NamespacedName{Namespace: namespace, Name: name}]
+		if !ok {
+			return fmt.Sprintf("Issuer %s not found", name)
+		}
+		if ready, message := informer.GetIssuerReadyMessage(issuer.Status.Conditions); !ready {
+			return fmt.Sprintf("Issuer %s not ready: %s", name, message)
+		}
+		return ""
+	}
+
+	report := &Report{}
+	owners := map[types.UID]bool{}
+	chains := map[string]bool{}
+	for i := range skupperCerts.Items {
+		obj := &skupperCerts.Items[i]
+		owners[obj.UID] = true
+		if !certmgr.IsManaged(obj) {
+			continue
+		}
+		name := types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name}
+		resolution := certmgr.ResolveIssuer(obj)
+		status := Certificate{
+			Namespace:    obj.Namespace,
+			Name:         obj.Name,
+			CA:           obj.Spec.Ca,
+			Signing:      obj.Spec.Signing,
+			Issuer:       resolution.Name,
+			IssuerKind:   "Issuer",
+			IssuerSource: resolution.Source,
+		}
+		if resolution.ClusterIssuer {
+			status.IssuerKind = "ClusterIssuer"
+			if problem := clusterIssuerProblem(resolution.Name); problem != "" {
+				status.Problems = append(status.Problems, problem)
+			}
+		} else if problem := issuerProblem(obj.Namespace, resolution.Name); problem != "" {
+			status.Problems = append(status.Problems, problem)
+		}
+		if obj.Spec.Signing {
+			chains[obj.Namespace] = true
+		}
+		if cmCert, ok := certsByName[name]; ok {
+			_, status.Ready = informer.GetCertManagerCertificateReadyReason(cmCert)
+		} else if informer.IsAdopted(obj) {
+			status.Ready = informer.ConditionTypeAdopted
+		} else {
+			status.Ready = "-"
+			status.Problems = append(status.Problems, "cert-manager Certificate not found")
+		}
+		if obj.Spec.Signing {
+			if issuer, ok := issuersByName[name]; !ok {
+				status.Problems = append(status.Problems, "CA Issuer not found")
+			} else if ready, message := informer.GetIssuerReadyMessage(issuer.Status.Conditions); !ready {
+				status.Problems = append(status.Problems, "CA Issuer not ready: "+message)
+			}
+		}
+		expiry, err := secretExpiry(ctx, cli, obj.Namespace, obj.Name)
+		if err != nil {
+			status.Problems = append(status.Problems, err.Error())
+		}
+		status.Expiry = expiry
+		report.Certificates = append(report.Certificates, status)
+	}
+
+	for chainNamespace := range chains {
+		chain := certmgr.GetChain(chainNamespace)
+		for i, intermediate := range chain {
+			status := Issuer{
+				Namespace: chainNamespace,
+				Kind:      "Issuer",
+				Name:      intermediate.Name,
+				Role:      "intermediate",
+			}
+			var problems []string
+			if problem := issuerProblem(chainNamespace, intermediate.Name); problem != "" {
+				problems = append(problems, problem)
+			}
+			levels := certmgr.ChainLevels(chain, i)
+			cert, err := secretCertificate(ctx, cli, chainNamespace, intermediate.Name)
+			if err != nil {
+				problems = append(problems, err.Error())
+			} else if cert != nil {
+				problem, unconstrained := certmgr.PathLenProblem(cert, levels)
+				if problem != "" {
+					problems = append(problems, problem)
+				}
+				status.PathLen = fmt.Sprintf("%d (%d expected)", cert.MaxPathLen, levels)
+				if unconstrained {
+					status.PathLen = fmt.Sprintf("unconstrained (%d expected)", levels)
+				}
+			}
+			status.Problem = strings.Join(problems, "; ")
+			report.Issuers = append(report.Issuers, status)
+		}
+	}
+	if sharedRoot := certmgr.GetSharedRoot(); sharedRoot != nil {
+		problem := clusterIssuerProblem(sharedRoot.Name)
+		if problem != "" {
+			problem += " (cert-manager --cluster-resource-namespace must be the shared root namespace)"
+		}
+		report.Issuers = append(report.Issuers, Issuer{
+			Kind:    "ClusterIssuer",
+			Name:    sharedRoot.Name,
+			Role:    "shared root",
+			Problem: problem,
+		})
+	}
+	slices.SortFunc(report.Issuers, func(a, b Issuer) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	for _, cert := range cmCerts.Items {
+		if problem := orphaned(&cert, owners); problem != "" {
+			report.Orphans = append(report.Orphans, Orphan{Namespace: cert.Namespace, Kind: "Certificate", Name: cert.Name, Problem: problem})
+		}
+	}
+	for _, issuer := range issuers.Items {
+		if problem := orphaned(&issuer, owners); problem != "" {
+			report.Orphans = append(report.Orphans, Orphan{Namespace: issuer.Namespace, Kind: "Issuer", Name: issuer.Name, Problem: problem})
+		}
+	}
+	return report, nil
+}
+
+// orphaned tells why a resource owned by a Skupper Certificate, whether
+// annotated with a spec hash or not, is orphaned.
+func orphaned(obj v1.Object, owners map[types.UID]bool) string {
+	if !client.IsOwnedBySkupper(obj) {
+		return ""
+	}
+	if !owners[v1.GetControllerOf(obj).UID] {
+		return "Skupper owner not found"
+	}
+	return ""
+}
+
+// secretExpiry returns the expiry of the certificate held by the given
+// Secret, if it has been issued yet.
+func secretExpiry(ctx context.Context, cli *client.Client, namespace, name string) (*time.Time, error) {
+	cert, err := secretCertificate(ctx, cli, namespace, name)
+	if cert == nil || err != nil {
+		return nil, err
+	}
+	return &cert.NotAfter, nil
+}
+
+// secretCertificate returns the certificate held by the given Secret, if it
+// has been issued yet.
+func secretCertificate(ctx context.Context, cli *client.Client, namespace, name string) (*x509.Certificate, error) {
+	secret, err := cli.Kube.CoreV1().Secrets(namespace).Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secret: %w", err)
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		return nil, fmt.Errorf("secret holds no certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("secret holds an invalid certificate: %w", err)
+	}
+	return cert, nil
+}
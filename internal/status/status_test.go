@@ -0,0 +1,43 @@
+package status
+
+import (
+	"testing"
+
+	cm "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestOrphaned(t *testing.T) {
+	controller := true
+	owned := func(apiVersion string, uid types.UID) *cm.Certificate {
+		return &cm.Certificate{ObjectMeta: v1.ObjectMeta{
+			Name: "test",
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: apiVersion,
+				Kind:       "Certificate",
+				Name:       "test",
+				UID:        uid,
+				Controller: &controller,
+			}},
+		}}
+	}
+	owners := map[types.UID]bool{"present": true}
+	tests := []struct {
+		name string
+		obj  v1.Object
+		want string
+	}{
+		{"not owned", &cm.Certificate{}, ""},
+		{"owned by something else", owned("example.com/v1", "missing"), ""},
+		{"owner present", owned("skupper.io/v2alpha1", "present"), ""},
+		{"owner missing", owned("skupper.io/v2alpha1", "missing"), "Skupper owner not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := orphaned(test.obj, owners); got != test.want {
+				t.Errorf("orphaned() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,10 @@
 package main
 
 import (
-	"errors"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"skupper-cert-manager/internal/kube/client"
-	"skupper-cert-manager/internal/kube/informer"
+	"skupper-cert-manager/internal/cmd"
 )
 
 /*
@@ -36,24 +32,7 @@ TODO - Tasks breakdown
 */
 
 func main() {
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	stopCh := make(chan struct{})
-	cli, err := client.NewClient("", "")
-	if err != nil {
+	if err := cmd.Run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
-	skpCertInformer := informer.NewSkupperCertificateInformer(cli, "")
-	cmCertInformer := informer.NewCertMgrCertificateInformer(cli, "")
-	eventProcessor := client.NewEventProcessor("")
-	var informerErrors []error
-	for _, i := range []client.EventInformer{skpCertInformer, cmCertInformer} {
-		informerErrors = append(informerErrors, eventProcessor.AddInformer(i))
-	}
-	if errors.Join(informerErrors...) != nil {
-		log.Fatal(err)
-	}
-	eventProcessor.StartInformers(stopCh)
-	eventProcessor.Start(stopCh)
-	<-sigs
 }